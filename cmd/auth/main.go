@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/app"
 	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/config"
@@ -23,13 +24,49 @@ func main() {
 	// Closing DB
 	defer application.PG.Close(ctx)
 
+	// Users created before canonical usernames can not log in until they have one
+	if err := application.BackfillCanonicalUsernames(ctx); err != nil {
+		panic("failed to backfill canonical usernames: " + err.Error())
+	}
+
+	// First admin can not be assigned through admin api
+	if err := application.BootstrapAdmins(ctx); err != nil {
+		panic("failed to bootstrap admins: " + err.Error())
+	}
+
 	go func() { application.GRPCServer.MustRun(ctx) }()
 
+	if application.HTTPServer != nil {
+		go func() { application.HTTPServer.MustRun(ctx) }()
+	}
+
+	// Reloading signing keys
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+
+	go func() {
+		for range reload {
+			application.ReloadKeys(ctx)
+		}
+	}()
+
+	// Pruning audit events
+	go func() {
+		for {
+			application.PruneAuditEvents(ctx)
+			time.Sleep(time.Hour)
+		}
+	}()
+
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 
 	<-stop
 
-	// Stopping server
+	// Stopping servers
 	application.GRPCServer.Stop(ctx)
+
+	if application.HTTPServer != nil {
+		application.HTTPServer.Stop(ctx)
+	}
 }
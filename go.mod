@@ -9,7 +9,18 @@ require (
 
 require github.com/golang-jwt/jwt v3.2.2+incompatible
 
-require github.com/MAXXXIMUS-tropical-milkshake/beatflow-protos v0.0.1
+require github.com/MAXXXIMUS-tropical-milkshake/beatflow-protos v0.0.2
+
+require (
+	github.com/fxamacker/cbor/v2 v2.5.0 // indirect
+	github.com/go-webauthn/webauthn v0.9.4
+	github.com/go-webauthn/x v0.1.5 // indirect
+	github.com/golang-jwt/jwt/v5 v5.2.0 // indirect
+	github.com/google/go-tpm v0.9.0 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/mitchellh/mapstructure v1.5.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+)
 
 require (
 	github.com/grpc-ecosystem/go-grpc-middleware/v2 v2.1.0
@@ -22,8 +33,11 @@ require (
 	golang.org/x/net v0.29.0 // indirect
 	golang.org/x/sync v0.8.0 // indirect
 	golang.org/x/sys v0.25.0 // indirect
-	golang.org/x/text v0.18.0 // indirect
-	google.golang.org/genproto/googleapis/rpc v0.0.0-20240903143218-8af14fe29dc1 // indirect
+	golang.org/x/text v0.18.0
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240903143218-8af14fe29dc1
 	google.golang.org/grpc v1.67.0
-	google.golang.org/protobuf v1.34.2 // indirect
+	google.golang.org/protobuf v1.34.2
 )
+
+// ./protos is the content of beatflow-protos v0.0.2, the replace is removed once the tag is published
+replace github.com/MAXXXIMUS-tropical-milkshake/beatflow-protos => ./protos
@@ -2,18 +2,67 @@ package app
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"time"
 
 	grpcapp "github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/app/gprc"
+	httpapp "github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/app/http"
 	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/config"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/canonical"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/jwt"
 	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/logger"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/notifier"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/passkey"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/password"
 	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/postgres"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/pwned"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/ratelimit"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/secretbox"
 	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/service/auth"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/store/postgres/audit"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/store/postgres/lockout"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/store/postgres/mfa"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/store/postgres/otp"
+	passkeystore "github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/store/postgres/passkey"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/store/postgres/phone"
+	ratelimitstore "github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/store/postgres/ratelimit"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/store/postgres/reset"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/store/postgres/revocation"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/store/postgres/role"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/store/postgres/session"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/store/postgres/token"
 	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/store/postgres/user"
 )
 
+// auditPruneBatch is the number of audit events deleted by one statement
+const auditPruneBatch = 1000
+
+const (
+	// usernameBackfillBatch is the number of users canonicalized at once
+	usernameBackfillBatch = 1000
+	// maxStoredUsernameLength is the length of username column
+	maxStoredUsernameLength = 64
+)
+
 type App struct {
 	GRPCServer *grpcapp.App
+	HTTPServer *httpapp.App
 	PG         *postgres.Postgres
+	Keyring    *jwt.Keyring
+
+	Auth           core.AuthService
+	UserStore      core.UserStore
+	RoleStore      core.RoleStore
+	AuditStore     core.AuditStore
+	AuditRetention time.Duration
+
+	BootstrapAdminUsernames []string
 }
 
 func New(ctx context.Context, cfg *config.Config) *App {
@@ -26,20 +75,427 @@ func New(ctx context.Context, cfg *config.Config) *App {
 		logger.Log().Fatal(ctx, "error with connection to database: %s", err.Error())
 	}
 
+	// Signing keys
+	keyring, err := jwt.NewKeyring(keyLoader(cfg))
+	if err != nil {
+		logger.Log().Fatal(ctx, "failed to load signing keys: %s", err.Error())
+	}
+
+	verifier := jwt.NewVerifier(keyring, jwt.ValidationOptions{
+		Issuer:    cfg.Issuer,
+		Audiences: cfg.AllowedAudience,
+		Leeway:    time.Duration(cfg.Leeway) * time.Second,
+	})
+
 	// Auth config
-	authConfig := auth.NewConfig(cfg.JWTSecret, cfg.TokenTTL)
+	authConfig := auth.NewConfig(
+		cfg.TokenTTL,
+		cfg.RefreshTokenTTL,
+		cfg.Issuer,
+		cfg.Audience,
+		cfg.PasswordResetTTL,
+		cfg.PasswordResetURL,
+		cfg.EmailVerificationTTL,
+		cfg.EmailVerificationURL,
+		cfg.RequireVerifiedEmail,
+	)
+
+	// Password policy
+	breachedPasswords, err := newBreachedPasswordChecker(cfg)
+	if err != nil {
+		logger.Log().Fatal(ctx, "failed to load breached passwords: %s", err.Error())
+	}
+
+	// Password hashing
+	passwordHasher, err := newPasswordHasher(cfg)
+	if err != nil {
+		logger.Log().Fatal(ctx, "failed to create password hasher: %s", err.Error())
+	}
+
+	passwordPepper, err := newPepper(cfg)
+	if err != nil {
+		logger.Log().Fatal(ctx, "failed to load password pepper: %s", err.Error())
+	}
+
+	passwordPolicy := auth.NewPasswordPolicy(core.PasswordPolicyConfig{
+		MinLength:       cfg.PasswordPolicy.MinLength,
+		MaxLength:       cfg.PasswordPolicy.MaxLength,
+		MinClasses:      cfg.PasswordPolicy.MinClasses,
+		BreachThreshold: cfg.BreachThreshold,
+		HashScheme:      cfg.PasswordHasher.Algorithm,
+		Peppered:        passwordPepper.CurrentVersion() != 0,
+	}, breachedPasswords)
+
+	// Encryption of totp secrets
+	secretCipher, err := newSecretCipher(cfg)
+	if err != nil {
+		logger.Log().Fatal(ctx, "failed to load mfa encryption key: %s", err.Error())
+	}
+
+	// Passkeys
+	relyingParty, err := newRelyingParty(cfg)
+	if err != nil {
+		logger.Log().Fatal(ctx, "failed to configure passkeys: %s", err.Error())
+	}
+
+	// Notifier
+	notifier, err := newNotifier(cfg)
+	if err != nil {
+		logger.Log().Fatal(ctx, "failed to create notifier: %s", err.Error())
+	}
+
+	smsNotifier, err := newSMSNotifier(cfg)
+	if err != nil {
+		logger.Log().Fatal(ctx, "failed to create sms notifier: %s", err.Error())
+	}
 
 	// Store
 	userStore := user.New(pg)
+	lockoutStore := lockout.New(pg)
+	tokenStore := token.New(pg)
+	revocationStore := revocation.New(pg)
+	resetStore := reset.New(pg)
+	mfaStore := mfa.New(pg)
+	passkeyStore := passkeystore.New(pg)
+	otpStore := otp.New(pg)
+	phoneStore := phone.New(pg)
+	sessionStore := session.New(pg)
+	roleStore := role.New(pg)
+	auditStore := audit.New(pg)
 
 	// Service
-	authService := auth.New(userStore, authConfig)
+	loginLockout := auth.NewLockout(lockoutStore, core.LockoutConfig{
+		UserBackoffAfter: cfg.UserBackoffAfter,
+		IPBackoffAfter:   cfg.IPBackoffAfter,
+		BackoffBase:      time.Duration(cfg.BackoffBase) * time.Second,
+		BackoffMax:       time.Duration(cfg.BackoffMax) * time.Second,
+		LockAfter:        cfg.LockAfter,
+		LockDuration:     time.Duration(cfg.LockDuration) * time.Minute,
+		FailureWindow:    time.Duration(cfg.FailureWindow) * time.Minute,
+	})
+	otpConfig := core.OTPConfig{
+		Length:      cfg.OTP.Length,
+		TTL:         time.Duration(cfg.OTP.TTL) * time.Minute,
+		MaxAttempts: cfg.OTP.MaxAttempts,
+	}
+	revocationService := auth.NewRevocation(revocationStore, sessionStore, time.Duration(cfg.RevocationCacheTTL)*time.Second)
+	authService := auth.New(userStore, tokenStore, resetStore, mfaStore, passkeyStore, sessionStore, roleStore, auditStore, otpStore, phoneStore, revocationService, notifier, smsNotifier, passwordPolicy, passwordHasher, passwordPepper, loginLockout, secretCipher, relyingParty, otpConfig, authConfig, keyring, verifier)
 
 	// gRPC server
-	gRPCApp := grpcapp.New(ctx, authService, cfg)
+	rateLimiter, err := newRateLimiter(cfg, pg)
+	if err != nil {
+		logger.Log().Fatal(ctx, "failed to create rate limiter: %s", err.Error())
+	}
+
+	gRPCApp := grpcapp.New(ctx, authService, revocationService, rateLimiter, verifier, cfg)
+
+	// HTTP server for jwks
+	var httpApp *httpapp.App
+	if cfg.JWKSPort != "" {
+		httpApp = httpapp.New(ctx, authService, cfg)
+	}
 
 	return &App{
 		GRPCServer: gRPCApp,
+		HTTPServer: httpApp,
 		PG:         pg,
+		Keyring:    keyring,
+
+		Auth:           authService,
+		UserStore:      userStore,
+		RoleStore:      roleStore,
+		AuditStore:     auditStore,
+		AuditRetention: time.Duration(cfg.Audit.Retention) * 24 * time.Hour,
+
+		BootstrapAdminUsernames: cfg.BootstrapAdmins,
+	}
+}
+
+// ReloadKeys reloads signing keys from disk, current keys are kept on error
+func (a *App) ReloadKeys(ctx context.Context) {
+	if err := a.Keyring.Reload(); err != nil {
+		logger.Log().Error(ctx, "failed to reload signing keys: %s", err.Error())
+		return
+	}
+
+	logger.Log().Info(ctx, "signing keys reloaded")
+}
+
+// PruneAuditEvents deletes audit events older than retention in batches
+func (a *App) PruneAuditEvents(ctx context.Context) {
+	if a.AuditRetention <= 0 {
+		return
+	}
+
+	before := time.Now().Add(-a.AuditRetention)
+
+	var total int64
+	for {
+		deleted, err := a.AuditStore.DeleteAuditEvents(ctx, before, auditPruneBatch)
+		if err != nil {
+			logger.Log().Error(ctx, "failed to prune audit events: %s", err.Error())
+			return
+		}
+
+		total += deleted
+		if deleted < auditPruneBatch {
+			break
+		}
+	}
+
+	if total > 0 {
+		logger.Log().Info(ctx, "pruned %d audit events", total)
+	}
+}
+
+// BackfillCanonicalUsernames sets canonical usernames of users created before them.
+// Usernames that collide with older ones are renamed by appending user id,
+// so the older user keeps the name and the renamed user can still log in
+func (a *App) BackfillCanonicalUsernames(ctx context.Context) error {
+	var total, renamed int
+	for {
+		users, err := a.UserStore.GetUsersWithoutCanonicalUsername(ctx, usernameBackfillBatch)
+		if err != nil {
+			return err
+		}
+
+		for _, u := range users {
+			u.CanonicalUsername = canonical.Username(u.Username)
+
+			err = a.UserStore.SetCanonicalUsername(ctx, u)
+			if errors.Is(err, core.ErrUserAlreadyExists) {
+				username := u.Username
+				u.Username = collisionUsername(u.Username, u.ID)
+				u.CanonicalUsername = canonical.Username(u.Username)
+
+				err = a.UserStore.SetCanonicalUsername(ctx, u)
+				if err == nil {
+					logger.Log().Warn(ctx, "username %q of user %d collides with another user, renamed to %q",
+						username, u.ID, u.Username)
+					renamed++
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("failed to set canonical username of user %d: %w", u.ID, err)
+			}
+
+			total++
+		}
+
+		if len(users) < usernameBackfillBatch {
+			break
+		}
 	}
+
+	if total > 0 {
+		logger.Log().Info(ctx, "canonical usernames set for %d users, %d renamed", total, renamed)
+	}
+
+	return nil
+}
+
+// BootstrapAdmins assigns admin role to configured users that do not have it yet,
+// so the first admin does not need the admin api. Role is assigned like by an admin
+// with actor 0 in audit log. Users that do not exist yet are skipped until next start
+func (a *App) BootstrapAdmins(ctx context.Context) error {
+	for _, username := range a.BootstrapAdminUsernames {
+		u, err := a.UserStore.GetUserByUsername(ctx, canonical.Username(username))
+		if errors.Is(err, core.ErrInvalidCredentials) {
+			logger.Log().Warn(ctx, "bootstrap admin %q does not exist", username)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get bootstrap admin %q: %w", username, err)
+		}
+
+		roles, _, err := a.RoleStore.GetUserRoles(ctx, u.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get roles of bootstrap admin %q: %w", username, err)
+		}
+
+		if slices.Contains(roles, core.RoleAdmin) {
+			continue
+		}
+
+		err = a.Auth.AssignRole(ctx, 0, u.ID, core.RoleAdmin)
+		if err != nil {
+			return fmt.Errorf("failed to assign admin role to %q: %w", username, err)
+		}
+
+		logger.Log().Info(ctx, "admin role assigned to bootstrap admin %q", username)
+	}
+
+	return nil
+}
+
+// collisionUsername appends user id to the username, it is unique
+// as long as no other username ends with the same id
+func collisionUsername(username string, userID int) string {
+	suffix := "-" + strconv.Itoa(userID)
+
+	runes := []rune(username)
+	for len(string(runes))+len(suffix) > maxStoredUsernameLength {
+		runes = runes[:len(runes)-1]
+	}
+
+	return string(runes) + suffix
+}
+
+func keyLoader(cfg *config.Config) jwt.KeyLoader {
+	if cfg.JWTKeyring != "" {
+		return jwt.KeyringFile(cfg.JWTKeyring)
+	}
+
+	return jwt.StaticKey(func() (*jwt.Key, error) {
+		if cfg.JWTAlgorithm == "HS256" {
+			return jwt.NewHMACKey(cfg.JWTKeyID, cfg.JWTSecret)
+		}
+
+		return jwt.LoadKey(cfg.JWTKeyID, cfg.JWTAlgorithm, cfg.JWTPrivateKey)
+	})
+}
+
+func newBreachedPasswordChecker(cfg *config.Config) (core.BreachedPasswordChecker, error) {
+	if cfg.BreachedPasswords == "" {
+		return nil, nil
+	}
+
+	source, err := pwned.Open(cfg.BreachedPasswords)
+	if err != nil {
+		return nil, err
+	}
+
+	return pwned.NewChecker(source), nil
+}
+
+func newPasswordHasher(cfg *config.Config) (core.PasswordHasher, error) {
+	// Values are checked before conversion, so they can not wrap around
+	switch {
+	case cfg.Argon2Parallelism < 1 || cfg.Argon2Parallelism > math.MaxUint8:
+		return nil, fmt.Errorf("argon2 parallelism must be from 1 to %d", math.MaxUint8)
+	case cfg.Argon2Memory < 1 || uint64(cfg.Argon2Memory) > math.MaxUint32:
+		return nil, fmt.Errorf("argon2 memory must be from 1 to %d KiB", uint32(math.MaxUint32))
+	case cfg.Argon2Iterations < 1 || uint64(cfg.Argon2Iterations) > math.MaxUint32:
+		return nil, fmt.Errorf("argon2 iterations must be from 1 to %d", uint32(math.MaxUint32))
+	}
+
+	bcrypt, err := password.NewBcrypt(cfg.BcryptCost)
+	if err != nil {
+		return nil, err
+	}
+
+	argon2id, err := password.NewArgon2id(password.Argon2idParams{
+		Memory:      uint32(cfg.Argon2Memory),
+		Iterations:  uint32(cfg.Argon2Iterations),
+		Parallelism: uint8(cfg.Argon2Parallelism),
+		SaltLength:  16,
+		KeyLength:   32,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.PasswordHasher.Algorithm {
+	case "argon2id":
+		return password.New(argon2id, bcrypt), nil
+	case "bcrypt":
+		return password.New(bcrypt, argon2id), nil
+	}
+
+	return nil, fmt.Errorf("unknown password hasher %s", cfg.PasswordHasher.Algorithm)
+}
+
+func newPepper(cfg *config.Config) (core.Pepper, error) {
+	switch {
+	case cfg.Pepper != "":
+		return password.ParsePepper([]byte(cfg.Pepper))
+	case cfg.PepperFile != "":
+		return password.LoadPepper(cfg.PepperFile)
+	}
+
+	return password.NewPepper(0, nil)
+}
+
+// newRateLimiter returns nil if rate limiting is disabled
+func newRateLimiter(cfg *config.Config, pg *postgres.Postgres) (core.RateLimiter, error) {
+	switch cfg.RateLimit.Backend {
+	case "memory":
+		return ratelimit.NewMemory(), nil
+	case "postgres":
+		return ratelimitstore.New(pg), nil
+	case "none":
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("unknown rate limit backend %s", cfg.RateLimit.Backend)
+}
+
+// newSecretCipher returns nil if no key is configured, totp is disabled then
+func newSecretCipher(cfg *config.Config) (core.SecretCipher, error) {
+	var (
+		key []byte
+		err error
+	)
+
+	switch {
+	case cfg.MFAEncryptionKey != "":
+		key, err = base64.StdEncoding.DecodeString(cfg.MFAEncryptionKey)
+	case cfg.MFAEncryptionKeyFile != "":
+		key, err = secretbox.LoadKey(cfg.MFAEncryptionKeyFile)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return secretbox.New(key)
+}
+
+// newRelyingParty returns nil if no relying party id is configured, passkeys are disabled then
+func newRelyingParty(cfg *config.Config) (core.PasskeyRelyingParty, error) {
+	if cfg.WebAuthn.RPID == "" {
+		return nil, nil
+	}
+
+	return passkey.New(passkey.Config{
+		RPID:          cfg.WebAuthn.RPID,
+		RPDisplayName: cfg.WebAuthn.RPDisplayName,
+		RPOrigins:     cfg.WebAuthn.RPOrigins,
+		Timeout:       auth.PasskeySessionTTL,
+	})
+}
+
+func newNotifier(cfg *config.Config) (core.Notifier, error) {
+	switch cfg.Notifier.Type {
+	case "log":
+		return notifier.NewLog(), nil
+	case "file":
+		return notifier.NewFile(cfg.Notifier.File), nil
+	case "smtp":
+		return notifier.NewSMTP(notifier.SMTPConfig{
+			Host:     cfg.Notifier.SMTPHost,
+			Port:     cfg.Notifier.SMTPPort,
+			Username: cfg.Notifier.SMTPUsername,
+			Password: cfg.Notifier.SMTPPassword,
+			From:     cfg.Notifier.SMTPFrom,
+		}), nil
+	}
+
+	return nil, fmt.Errorf("unknown notifier %s", cfg.Notifier.Type)
+}
+
+// newSMSNotifier returns nil if sms is disabled, codes are sent only by email then.
+// Sinks of notifier package accept any address, so they are used for sms as well
+func newSMSNotifier(cfg *config.Config) (core.Notifier, error) {
+	switch cfg.Notifier.SMSType {
+	case "log":
+		return notifier.NewLog(), nil
+	case "file":
+		return notifier.NewFile(cfg.Notifier.SMSFile), nil
+	case "none":
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("unknown sms notifier %s", cfg.Notifier.SMSType)
 }
@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/config"
 	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
 	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/grpc/auth"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/jwt"
 	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/logger"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
@@ -25,13 +27,82 @@ type App struct {
 func New(
 	ctx context.Context,
 	userService core.AuthService,
+	revocationService core.RevocationService,
+	rateLimiter core.RateLimiter,
+	verifier *jwt.Verifier,
 	cfg *config.Config,
 ) *App {
-	// Methods that require authentication
-	requireAuth := map[string]bool{
-		"/auth.Auth/Login":          false,
-		"/auth.Auth/Signup":         false,
-		"/auth.Auth/UpdatePassword": true,
+	// Methods without policy are denied, so every method must be listed
+	policies, err := auth.LoadMethodPolicies(cfg.MethodPolicies)
+	if err != nil {
+		logger.Log().Fatal(ctx, "failed to load method policies: %v", err)
+	}
+
+	// Default rate limits of methods, overridden by rate limits file
+	rateLimits := map[string][]core.RateLimit{
+		"/auth.Auth/Login": {
+			{By: core.RateLimitByIP, Requests: 20, Period: time.Minute},
+			{By: core.RateLimitByUsername, Requests: 10, Period: time.Minute},
+		},
+		"/auth.Auth/Signup": {
+			{By: core.RateLimitByIP, Requests: 5, Period: time.Hour},
+		},
+		"/auth.Auth/Refresh": {
+			{By: core.RateLimitByIP, Requests: 60, Period: time.Minute},
+		},
+		"/auth.Auth/UpdatePassword": {
+			{By: core.RateLimitByUser, Requests: 5, Period: time.Hour},
+		},
+		"/auth.Auth/RequestPasswordReset": {
+			{By: core.RateLimitByIP, Requests: 10, Period: time.Hour},
+			{By: core.RateLimitByUsername, Requests: 3, Period: time.Hour},
+		},
+		"/auth.Auth/ConfirmPasswordReset": {
+			{By: core.RateLimitByIP, Requests: 10, Period: time.Minute},
+		},
+		"/auth.Auth/ConfirmTOTP": {
+			{By: core.RateLimitByUser, Requests: 10, Period: time.Minute},
+		},
+		"/auth.Auth/VerifyMFA": {
+			{By: core.RateLimitByIP, Requests: 20, Period: time.Minute},
+		},
+		"/auth.Auth/BeginPasskeyLogin": {
+			{By: core.RateLimitByIP, Requests: 20, Period: time.Minute},
+		},
+		"/auth.Auth/FinishPasskeyLogin": {
+			{By: core.RateLimitByIP, Requests: 20, Period: time.Minute},
+		},
+		"/auth.Auth/StartOTPLogin": {
+			{By: core.RateLimitByIP, Requests: 20, Period: time.Hour},
+			{By: core.RateLimitByUsername, Requests: 5, Period: time.Hour},
+		},
+		"/auth.Auth/CompleteOTPLogin": {
+			{By: core.RateLimitByIP, Requests: 20, Period: time.Minute},
+		},
+		"/auth.Auth/SendVerificationEmail": {
+			{By: core.RateLimitByUser, Requests: 3, Period: time.Hour},
+		},
+		"/auth.Auth/VerifyEmail": {
+			{By: core.RateLimitByIP, Requests: 10, Period: time.Minute},
+		},
+		"/auth.Auth/SetPhone": {
+			{By: core.RateLimitByUser, Requests: 3, Period: time.Hour},
+		},
+		"/auth.Auth/VerifyPhone": {
+			{By: core.RateLimitByUser, Requests: 10, Period: time.Minute},
+		},
+	}
+
+	if cfg.RateLimit.File != "" {
+		rateLimits, err = auth.LoadRateLimits(cfg.RateLimit.File)
+		if err != nil {
+			logger.Log().Fatal(ctx, "failed to load rate limits: %v", err)
+		}
+	}
+
+	serviceClients, err := auth.LoadServiceClients(cfg.ServiceClients)
+	if err != nil {
+		logger.Log().Fatal(ctx, "failed to load service clients: %v", err)
 	}
 
 	opts := []grpc.ServerOption{}
@@ -53,11 +124,20 @@ func New(
 		}),
 	}
 
-	opts = append(opts, grpc.ChainUnaryInterceptor(
+	interceptors := []grpc.UnaryServerInterceptor{
 		recovery.UnaryServerInterceptor(recoveryOpts...),
 		logging.UnaryServerInterceptor(interceptorLogger(logger.Log()), loggingOpts...),
-		auth.EnsureValidToken(cfg.JWTSecret, requireAuth),
-	))
+		auth.ClientInfo(cfg.TrustForwardedFor),
+		auth.EnsureValidToken(verifier, revocationService, policies),
+		auth.EnsureServiceClient(serviceClients, policies),
+	}
+
+	// Rate limiter goes after authentication to limit by user
+	if rateLimiter != nil {
+		interceptors = append(interceptors, auth.RateLimit(rateLimiter, rateLimits))
+	}
+
+	opts = append(opts, grpc.ChainUnaryInterceptor(interceptors...))
 
 	// TLS
 	creds, err := credentials.NewServerTLSFromFile(cfg.Cert, cfg.Key)
@@ -73,6 +153,10 @@ func New(
 	// Register services
 	auth.Register(gRPCServer, userService)
 
+	if err := policies.Check(gRPCServer); err != nil {
+		logger.Log().Fatal(ctx, "invalid method policies: %v", err)
+	}
+
 	return &App{
 		gRPCServer: gRPCServer,
 		port:       cfg.Port,
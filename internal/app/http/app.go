@@ -0,0 +1,66 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/config"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/http/jwks"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/logger"
+)
+
+type App struct {
+	httpServer *http.Server
+	cert       string
+	key        string
+}
+
+func New(
+	ctx context.Context,
+	authService core.AuthService,
+	cfg *config.Config,
+) *App {
+	mux := http.NewServeMux()
+
+	// Register handlers
+	jwks.Register(mux, authService)
+
+	return &App{
+		httpServer: &http.Server{
+			Addr:              cfg.JWKSPort,
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+		cert: cfg.Cert,
+		key:  cfg.Key,
+	}
+}
+
+func (a *App) MustRun(ctx context.Context) {
+	if err := a.Run(ctx); err != nil {
+		logger.Log().Fatal(ctx, "failed to run http server: %v", err)
+	}
+}
+
+func (a *App) Run(ctx context.Context) error {
+	logger.Log().Info(ctx, fmt.Sprintf("http server started on port %s", a.httpServer.Addr))
+
+	err := a.httpServer.ListenAndServeTLS(a.cert, a.key)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+func (a *App) Stop(ctx context.Context) {
+	logger.Log().Info(ctx, "stopping http server")
+
+	if err := a.httpServer.Shutdown(ctx); err != nil {
+		logger.Log().Error(ctx, "failed to stop http server: %v", err)
+	}
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"flag"
+	"os"
+	"strings"
 
 	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/logger"
 )
@@ -13,10 +15,21 @@ type (
 		DB
 		TLS
 		Auth
+		PasswordPolicy
+		PasswordHasher
+		Lockout
+		RateLimit
+		Notifier
+		WebAuthn
+		OTP
+		Audit
 	}
 
 	HTTP struct {
-		Port string
+		Port     string
+		JWKSPort string
+		// Take client ip from x-forwarded-for set by proxy
+		TrustForwardedFor bool
 	}
 
 	Log struct {
@@ -33,29 +46,205 @@ type (
 	}
 
 	Auth struct {
-		JWTSecret string
-		TokenTTL  int
+		JWTSecret       string
+		JWTAlgorithm    string
+		JWTPrivateKey   string
+		JWTKeyID        string
+		JWTKeyring      string
+		Issuer          string
+		Audience        []string
+		AllowedAudience []string
+		Leeway          int
+		TokenTTL        int
+		RefreshTokenTTL int
+
+		RevocationCacheTTL int
+
+		ServiceClients string
+		MethodPolicies string
+		// BootstrapAdmins are usernames that get admin role at startup
+		BootstrapAdmins []string
+
+		PasswordResetTTL int
+		PasswordResetURL string
+
+		EmailVerificationTTL int
+		EmailVerificationURL string
+		RequireVerifiedEmail bool
+
+		// Base64 AES-256 key that encrypts totp secrets, takes precedence over the file
+		MFAEncryptionKey     string
+		MFAEncryptionKeyFile string
+	}
+
+	PasswordPolicy struct {
+		MinLength  int
+		MaxLength  int
+		MinClasses int
+
+		BreachedPasswords string
+		BreachThreshold   int
+	}
+
+	PasswordHasher struct {
+		Algorithm         string
+		BcryptCost        int
+		Argon2Memory      int
+		Argon2Iterations  int
+		Argon2Parallelism int
+
+		PepperFile string
+		// Pepper json, takes precedence over the file
+		Pepper string
+	}
+
+	Lockout struct {
+		UserBackoffAfter int
+		IPBackoffAfter   int
+		BackoffBase      int
+		BackoffMax       int
+		LockAfter        int
+		LockDuration     int
+		FailureWindow    int
+	}
+
+	RateLimit struct {
+		Backend string
+		File    string
+	}
+
+	Notifier struct {
+		Type string
+		File string
+
+		SMTPHost     string
+		SMTPPort     string
+		SMTPUsername string
+		SMTPPassword string
+		SMTPFrom     string
+
+		SMSType string
+		SMSFile string
+	}
+
+	WebAuthn struct {
+		RPID          string
+		RPDisplayName string
+		RPOrigins     []string
+	}
+
+	OTP struct {
+		Length      int
+		TTL         int
+		MaxAttempts int
+	}
+
+	Audit struct {
+		// Retention in days, events are kept forever if 0
+		Retention int
 	}
 )
 
 func NewConfig() (*Config, error) {
 	port := flag.String("port", "8080", "HTTP port")
+	jwksPort := flag.String("jwks_port", "", "HTTP port for jwks endpoint, disabled if empty")
 	logLevel := flag.String("log_level", string(logger.InfoLevel), "logger level")
 	dbURL := flag.String("db_url", "", "url for connection to database")
 
+	trustForwardedFor := flag.Bool("trust_forwarded_for", false, "take client ip from x-forwarded-for, enable only behind a proxy")
+
 	// TLS
 	cert := flag.String("cert", "", "path to cert file")
 	key := flag.String("key", "", "path to key file")
 
 	// JWT
-	jwtSecret := flag.String("jwt_secret", "", "jwt secret")
+	jwtSecret := flag.String("jwt_secret", "", "jwt secret, used only with HS256")
+	jwtAlgorithm := flag.String("jwt_algorithm", "HS256", "jwt signing algorithm: HS256, RS256, ES256 or EdDSA")
+	jwtPrivateKey := flag.String("jwt_private_key", "", "path to PEM private key for asymmetric algorithms")
+	jwtKeyID := flag.String("jwt_key_id", "", "jwt key id, key thumbprint is used if empty")
+	jwtKeyring := flag.String("jwt_keyring", "", "path to json keyring file, overrides single key flags")
+	issuer := flag.String("jwt_issuer", "beatflow-auth", "jwt issuer")
+	audience := flag.String("jwt_audience", "beatflow", "comma separated audiences of issued tokens")
+	allowedAudience := flag.String("jwt_allowed_audience", "", "comma separated audiences accepted by the service, jwt_audience is used if empty")
+	leeway := flag.Int("jwt_leeway", 30, "allowed clock skew in seconds")
 	tokenTTL := flag.Int("token_ttl", 10, "token ttl")
+	refreshTokenTTL := flag.Int("refresh_token_ttl", 720, "refresh token ttl in hours")
+	revocationCacheTTL := flag.Int("revocation_cache_ttl", 30, "revocation cache ttl in seconds")
+	serviceClients := flag.String("service_clients", "", "path to json file with service client credentials")
+	methodPolicies := flag.String("method_policies", "", "path to json file with authorization policies of methods, defaults are used if empty")
+	bootstrapAdmins := flag.String("bootstrap_admins", "", "comma separated usernames that get admin role at startup if they do not have it")
+
+	// Password reset
+	passwordResetTTL := flag.Int("password_reset_ttl", 30, "password reset token ttl in minutes")
+	passwordResetURL := flag.String("password_reset_url", "", "url of password reset page, token is added as query parameter")
+
+	// Email verification
+	emailVerificationTTL := flag.Int("email_verification_ttl", 24, "email verification token ttl in hours")
+	emailVerificationURL := flag.String("email_verification_url", "", "url of email verification page, token is added as query parameter")
+	requireVerifiedEmail := flag.Bool("require_verified_email", false, "deny login to users with unverified email, otherwise their tokens have email_verified claim set to false")
+
+	// Two-factor authentication
+	mfaEncryptionKey := flag.String("mfa_encryption_key", "", "path to file with base64 encoded 32 byte key for totp secrets, MFA_ENCRYPTION_KEY env variable is used if set, totp is disabled without key")
+
+	// Passkeys
+	webAuthnRPID := flag.String("webauthn_rp_id", "", "webauthn relying party id, domain of the site, passkeys are disabled if empty")
+	webAuthnRPName := flag.String("webauthn_rp_name", "beatflow", "webauthn relying party display name")
+	webAuthnOrigins := flag.String("webauthn_origins", "", "comma separated origins allowed to use passkeys")
+
+	// Password policy
+	passwordMinLength := flag.Int("password_min_length", 8, "minimum password length")
+	passwordMaxLength := flag.Int("password_max_length", 64, "maximum password length")
+	passwordMinClasses := flag.Int("password_min_classes", 2, "minimum number of character classes in password")
+	breachedPasswords := flag.String("breached_passwords", "", "path to directory of range files or file of SHA-1 hashes ordered by hash, disabled if empty")
+	breachThreshold := flag.Int("breach_threshold", 1, "minimum breach count for password to be rejected")
+
+	// Password hashing
+	passwordHasher := flag.String("password_hasher", "argon2id", "password hashing algorithm: argon2id or bcrypt, hashes of the other one are upgraded on login")
+	bcryptCost := flag.Int("bcrypt_cost", 10, "bcrypt cost")
+	argon2Memory := flag.Int("argon2_memory", 64*1024, "argon2id memory in KiB")
+	argon2Iterations := flag.Int("argon2_iterations", 3, "argon2id number of iterations")
+	argon2Parallelism := flag.Int("argon2_parallelism", 2, "argon2id degree of parallelism")
+	pepperFile := flag.String("password_pepper_file", "", "path to json file with password pepper secrets, PASSWORD_PEPPER env variable with the same json is used if set")
+
+	// Login lockout
+	userBackoffAfter := flag.Int("login_backoff_after", 3, "failed logins of a user after which attempts are delayed")
+	ipBackoffAfter := flag.Int("login_ip_backoff_after", 20, "failed logins from an ip after which attempts are delayed")
+	backoffBase := flag.Int("login_backoff_base", 1, "first login delay in seconds, doubled with every next failure")
+	backoffMax := flag.Int("login_backoff_max", 300, "maximum login delay in seconds")
+	lockAfter := flag.Int("login_lock_after", 10, "failed logins after which the account is locked, 0 disables lockout")
+	lockDuration := flag.Int("login_lock_duration", 15, "account lock duration in minutes")
+	failureWindow := flag.Int("login_failure_window", 60, "failed logins are forgotten after this many minutes without failures")
+
+	// Rate limits
+	rateLimitBackend := flag.String("rate_limit_backend", "memory", "rate limit backend: memory, postgres or none")
+	rateLimitFile := flag.String("rate_limits", "", "path to json file with rate limits of methods, defaults are used if empty")
+
+	// Notifier
+	notifierType := flag.String("notifier", "log", "notifier type: log, file or smtp")
+	notifierFile := flag.String("notifier_file", "notifications.log", "path to file for file notifier")
+	smtpHost := flag.String("smtp_host", "localhost", "smtp server host")
+	smtpPort := flag.String("smtp_port", "587", "smtp server port")
+	smtpUsername := flag.String("smtp_username", "", "smtp username, SMTP_PASSWORD env variable is used as password, no authentication if empty")
+	smtpFrom := flag.String("smtp_from", "", "sender address of emails")
+	smsNotifierType := flag.String("sms_notifier", "none", "sms notifier type: log, file or none, codes are not sent by sms if none")
+	smsNotifierFile := flag.String("sms_notifier_file", "sms.log", "path to file for file sms notifier")
+
+	// One-time code login
+	otpLength := flag.Int("otp_length", 6, "number of digits in login code")
+	otpTTL := flag.Int("otp_ttl", 10, "login code ttl in minutes")
+	otpMaxAttempts := flag.Int("otp_max_attempts", 5, "attempts to enter login code before a new one has to be requested")
+
+	// Audit
+	auditRetention := flag.Int("audit_retention", 365, "days audit events are kept for, 0 keeps them forever")
 
 	flag.Parse()
 
 	cfg := &Config{
 		HTTP: HTTP{
-			Port: *port,
+			Port:     *port,
+			JWKSPort: *jwksPort,
+
+			TrustForwardedFor: *trustForwardedFor,
 		},
 		Log: Log{
 			Level: *logLevel,
@@ -68,10 +257,108 @@ func NewConfig() (*Config, error) {
 			Key:  *key,
 		},
 		Auth: Auth{
-			JWTSecret: *jwtSecret,
-			TokenTTL:  *tokenTTL,
+			JWTSecret:       *jwtSecret,
+			JWTAlgorithm:    *jwtAlgorithm,
+			JWTPrivateKey:   *jwtPrivateKey,
+			JWTKeyID:        *jwtKeyID,
+			JWTKeyring:      *jwtKeyring,
+			Issuer:          *issuer,
+			Audience:        splitList(*audience),
+			AllowedAudience: splitList(*allowedAudience),
+			Leeway:          *leeway,
+			TokenTTL:        *tokenTTL,
+			RefreshTokenTTL: *refreshTokenTTL,
+
+			RevocationCacheTTL: *revocationCacheTTL,
+
+			ServiceClients: *serviceClients,
+			MethodPolicies: *methodPolicies,
+
+			BootstrapAdmins: splitList(*bootstrapAdmins),
+
+			PasswordResetTTL: *passwordResetTTL,
+			PasswordResetURL: *passwordResetURL,
+
+			EmailVerificationTTL: *emailVerificationTTL,
+			EmailVerificationURL: *emailVerificationURL,
+			RequireVerifiedEmail: *requireVerifiedEmail,
+
+			MFAEncryptionKey:     os.Getenv("MFA_ENCRYPTION_KEY"),
+			MFAEncryptionKeyFile: *mfaEncryptionKey,
+		},
+		PasswordPolicy: PasswordPolicy{
+			MinLength:  *passwordMinLength,
+			MaxLength:  *passwordMaxLength,
+			MinClasses: *passwordMinClasses,
+
+			BreachedPasswords: *breachedPasswords,
+			BreachThreshold:   *breachThreshold,
 		},
+		PasswordHasher: PasswordHasher{
+			Algorithm:         *passwordHasher,
+			BcryptCost:        *bcryptCost,
+			Argon2Memory:      *argon2Memory,
+			Argon2Iterations:  *argon2Iterations,
+			Argon2Parallelism: *argon2Parallelism,
+
+			PepperFile: *pepperFile,
+			Pepper:     os.Getenv("PASSWORD_PEPPER"),
+		},
+		Lockout: Lockout{
+			UserBackoffAfter: *userBackoffAfter,
+			IPBackoffAfter:   *ipBackoffAfter,
+			BackoffBase:      *backoffBase,
+			BackoffMax:       *backoffMax,
+			LockAfter:        *lockAfter,
+			LockDuration:     *lockDuration,
+			FailureWindow:    *failureWindow,
+		},
+		RateLimit: RateLimit{
+			Backend: *rateLimitBackend,
+			File:    *rateLimitFile,
+		},
+		Notifier: Notifier{
+			Type: *notifierType,
+			File: *notifierFile,
+
+			SMTPHost:     *smtpHost,
+			SMTPPort:     *smtpPort,
+			SMTPUsername: *smtpUsername,
+			SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+			SMTPFrom:     *smtpFrom,
+
+			SMSType: *smsNotifierType,
+			SMSFile: *smsNotifierFile,
+		},
+		WebAuthn: WebAuthn{
+			RPID:          *webAuthnRPID,
+			RPDisplayName: *webAuthnRPName,
+			RPOrigins:     splitList(*webAuthnOrigins),
+		},
+		OTP: OTP{
+			Length:      *otpLength,
+			TTL:         *otpTTL,
+			MaxAttempts: *otpMaxAttempts,
+		},
+		Audit: Audit{
+			Retention: *auditRetention,
+		},
+	}
+
+	if len(cfg.AllowedAudience) == 0 {
+		cfg.AllowedAudience = cfg.Audience
 	}
 
 	return cfg, nil
 }
+
+func splitList(s string) []string {
+	list := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,39 @@
+package core
+
+import "context"
+
+type (
+	// UserFilter selects users listed to admins, users are paged by id
+	UserFilter struct {
+		// Query is matched against usernames and emails
+		Query     string
+		Role      string
+		PageSize  int
+		PageToken string
+	}
+
+	UserPage struct {
+		Users []User
+		// NextPageToken is empty on the last page
+		NextPageToken string
+	}
+
+	// AdminService manages accounts of other users, every change
+	// is recorded in the audit trail with the admin as actor
+	AdminService interface {
+		ListUsers(ctx context.Context, filter UserFilter) (*UserPage, error)
+		GetUser(ctx context.Context, userID int) (*User, error)
+		DisableUser(ctx context.Context, actorID int, userID int, reason string) error
+		EnableUser(ctx context.Context, actorID int, userID int) error
+		// ForcePasswordReset reports whether reset token was sent,
+		// the user may have no address it can be delivered to
+		ForcePasswordReset(ctx context.Context, actorID int, userID int) (bool, error)
+		AssignRole(ctx context.Context, actorID int, userID int, role string) error
+		RemoveRole(ctx context.Context, actorID int, userID int, role string) error
+		DeleteUser(ctx context.Context, actorID int, userID int) error
+		// UnlockUser removes login lock of the user and forgets its failed logins,
+		// failed logins from the ip are forgotten too if it is not empty
+		UnlockUser(ctx context.Context, actorID int, userID int, ip string) error
+		ListAuditEvents(ctx context.Context, filter AuditFilter) (*AuditPage, error)
+	}
+)
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// Types of audit events
+const (
+	AuditLogin           = "user.login"
+	AuditSignup          = "user.signup"
+	AuditPasswordChanged = "user.password_changed"
+	AuditPasswordReset   = "user.password_reset"
+	AuditTokenRevoked    = "token.revoked"
+	AuditTokenReused     = "token.reused"
+	AuditSessionRevoked  = "session.revoked"
+	AuditSessionsRevoked = "session.revoked_all"
+	AuditPhoneChanged    = "user.phone_changed"
+
+	AuditUserDisabled        = "user.disabled"
+	AuditUserEnabled         = "user.enabled"
+	AuditPasswordResetForced = "user.password_reset_forced"
+	AuditRoleAssigned        = "user.role_assigned"
+	AuditRoleRemoved         = "user.role_removed"
+	AuditUserDeleted         = "user.deleted"
+	AuditUserUnlocked        = "user.unlocked"
+)
+
+// Outcomes of audit events
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+type (
+	AuditEvent struct {
+		ID   int64
+		Type string
+		// ActorID is the user who made the request, 0 if anonymous
+		ActorID int
+		// TargetID is the user affected by the event, 0 if none
+		TargetID  int
+		IP        string
+		UserAgent string
+		RequestID string
+		Outcome   string
+		// Details are free-form, like role name or reason
+		Details   string
+		CreatedAt time.Time
+	}
+
+	// AuditFilter selects audit events, events are paged from the newest
+	AuditFilter struct {
+		// UserID matches events where the user is actor or target
+		UserID int
+		Type   string
+		// Since and Until bound time of events if they are not zero
+		Since     time.Time
+		Until     time.Time
+		PageSize  int
+		PageToken string
+	}
+
+	AuditPage struct {
+		Events []AuditEvent
+		// NextPageToken is empty on the last page
+		NextPageToken string
+	}
+
+	AuditStore interface {
+		AddAuditEvent(ctx context.Context, event AuditEvent) error
+		// GetAuditEvents returns events older than beforeID if it is not zero, newest first
+		GetAuditEvents(ctx context.Context, filter AuditFilter, beforeID int64, limit int) ([]AuditEvent, error)
+		// DeleteAuditEvents deletes at most limit events created before the time
+		DeleteAuditEvents(ctx context.Context, before time.Time, limit int) (deleted int64, err error)
+	}
+)
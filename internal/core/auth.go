@@ -3,14 +3,45 @@ package core
 import "context"
 
 type (
+	// LoginResult has tokens, or mfa token if the second factor is required
+	LoginResult struct {
+		Tokens   *TokenPair
+		MFAToken string
+	}
+
 	AuthService interface {
-		Login(ctx context.Context, user User) (*string, error)
+		Login(ctx context.Context, user User) (*LoginResult, error)
 		Signup(ctx context.Context, user User) error
-		UpdatePassword(ctx context.Context, user User) error
+		UpdatePassword(ctx context.Context, claims Claims, oldPassword string, newPassword string) (*string, error)
+		Refresh(ctx context.Context, refreshToken string) (*TokenPair, error)
+		Logout(ctx context.Context, claims Claims) error
+		LogoutAll(ctx context.Context, userID int) error
+		GetJWKS(ctx context.Context) []JWK
+		Introspect(ctx context.Context, token string) (*Claims, error)
+		RequestPasswordReset(ctx context.Context, username string) error
+		ConfirmPasswordReset(ctx context.Context, token string, newPassword string) error
+		SendVerificationEmail(ctx context.Context, userID int) error
+		VerifyEmail(ctx context.Context, token string) error
+		PhoneService
+		MFAService
+		PasskeyService
+		OTPService
+		SessionService
+		AdminService
 	}
 
 	AuthConfig struct {
-		Secret   string
-		TokenTTL int
+		TokenTTL         int
+		RefreshTokenTTL  int
+		Issuer           string
+		Audience         []string
+		PasswordResetTTL int
+		PasswordResetURL string
+
+		EmailVerificationTTL int
+		EmailVerificationURL string
+		// RequireVerifiedEmail denies tokens to users with unverified email,
+		// otherwise they get tokens with email_verified claim set to false
+		RequireVerifiedEmail bool
 	}
 )
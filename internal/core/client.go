@@ -0,0 +1,27 @@
+package core
+
+import "context"
+
+type (
+	// ClientInfo describes the caller of the current request
+	ClientInfo struct {
+		IP        string
+		UserAgent string
+		// DeviceName is set by the client to name its session
+		DeviceName string
+		// RequestID is set by the gateway to correlate events of one request
+		RequestID string
+	}
+
+	clientInfoKey struct{}
+)
+
+func WithClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, info)
+}
+
+// ClientInfoFromContext returns caller of the request, zero value if unknown
+func ClientInfoFromContext(ctx context.Context) ClientInfo {
+	info, _ := ctx.Value(clientInfoKey{}).(ClientInfo)
+	return info
+}
@@ -8,4 +8,57 @@ var (
 	ErrInvalidAuthConfig  = errors.New("invalid secret")
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrUnauthorized       = errors.New("unauthorized")
+	ErrInactiveToken      = errors.New("token is not active")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrSamePassword       = errors.New("new password must differ from the current one")
+
+	// admin
+	ErrAccountDisabled       = errors.New("account is disabled")
+	ErrPasswordResetRequired = errors.New("password reset is required")
+	ErrRoleNotFound          = errors.New("role not found")
+	ErrSelfAdminAction       = errors.New("admins can not disable, delete or demote themselves")
+	ErrInvalidPageToken      = errors.New("invalid page token")
+
+	// email
+	ErrEmailAlreadyExists       = errors.New("email already exists")
+	ErrEmailNotVerified         = errors.New("email is not verified")
+	ErrEmailAlreadyVerified     = errors.New("email is already verified")
+	ErrNoEmail                  = errors.New("user has no email")
+	ErrInvalidVerificationToken = errors.New("invalid email verification token")
+
+	// phone
+	ErrPhoneAlreadyExists = errors.New("phone already exists")
+	ErrSMSNotConfigured   = errors.New("sms is not configured")
+
+	// lockout
+	ErrAccountLocked   = errors.New("account is locked")
+	ErrTooManyAttempts = errors.New("too many login attempts")
+
+	// mfa
+	ErrMFANotConfigured  = errors.New("two-factor authentication is not configured")
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled    = errors.New("two-factor authentication is not enrolled")
+	ErrInvalidMFAToken   = errors.New("invalid mfa token")
+	ErrInvalidMFACode    = errors.New("invalid mfa code")
+
+	// passkeys
+	ErrPasskeysNotConfigured  = errors.New("passkeys are not configured")
+	ErrInvalidPasskeySession  = errors.New("invalid passkey session")
+	ErrInvalidPasskeyResponse = errors.New("invalid passkey response")
+
+	// one-time codes
+	ErrInvalidOTP = errors.New("invalid or expired code")
+
+	// sessions
+	ErrSessionNotFound = errors.New("session not found")
+
+	// refresh token
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+
+	// password reset
+	ErrInvalidResetToken = errors.New("invalid password reset token")
+
+	// notifications
+	ErrNoRecipient = errors.New("notification has no recipient address")
 )
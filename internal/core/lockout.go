@@ -0,0 +1,55 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	LockoutScopeUser = "user"
+	LockoutScopeIP   = "ip"
+)
+
+type (
+	LockoutConfig struct {
+		// Number of failures after which every next attempt is delayed
+		UserBackoffAfter int
+		IPBackoffAfter   int
+		BackoffBase      time.Duration
+		BackoffMax       time.Duration
+		// Number of failures after which the account is locked, 0 disables lockout
+		LockAfter    int
+		LockDuration time.Duration
+		// Failures are forgotten after this period without new ones
+		FailureWindow time.Duration
+	}
+
+	// LoginFailures are failed logins of a user or from an ip
+	LoginFailures struct {
+		Failures     int
+		LastFailedAt time.Time
+		LockedUntil  *time.Time
+	}
+
+	LockoutStore interface {
+		GetLoginFailures(ctx context.Context, scope string, subject string) (*LoginFailures, error)
+		AddLoginFailure(ctx context.Context, scope string, subject string, window time.Duration) (*LoginFailures, error)
+		LockLogin(ctx context.Context, scope string, subject string, until time.Time) error
+		ClearLoginFailures(ctx context.Context, scope string, subject string) error
+	}
+
+	// RetryError is returned when action is rejected only for some time
+	RetryError struct {
+		Err        error
+		RetryAfter time.Duration
+	}
+)
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", e.Err.Error(), e.RetryAfter.Round(time.Second))
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,46 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// TOTP is authenticator app of the user, secret is encrypted
+	TOTP struct {
+		UserID       int
+		Secret       string
+		ConfirmedAt  *time.Time
+		LastUsedStep int64
+	}
+
+	TOTPEnrollment struct {
+		Secret string
+		URI    string
+	}
+
+	MFAService interface {
+		EnrollTOTP(ctx context.Context, userID int) (*TOTPEnrollment, error)
+		ConfirmTOTP(ctx context.Context, userID int, code string) (recoveryCodes []string, err error)
+		DisableTOTP(ctx context.Context, userID int, password string) error
+		VerifyMFA(ctx context.Context, mfaToken string, code string, recoveryCode string) (*TokenPair, error)
+	}
+
+	MFAStore interface {
+		// SetTOTP replaces not confirmed enrollment of the user
+		SetTOTP(ctx context.Context, totp TOTP) error
+		GetTOTP(ctx context.Context, userID int) (*TOTP, error)
+		// ConfirmTOTP enables the second factor and replaces recovery codes
+		ConfirmTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error
+		// UseTOTPStep fails with ErrInvalidMFACode if the step or a later one was used
+		UseTOTPStep(ctx context.Context, userID int, step int64) error
+		UseRecoveryCode(ctx context.Context, userID int, codeHash string) error
+		DeleteTOTP(ctx context.Context, userID int) error
+	}
+
+	// SecretCipher encrypts secrets that are stored in the database
+	SecretCipher interface {
+		Encrypt(plaintext []byte, associatedData []byte) (string, error)
+		Decrypt(ciphertext string, associatedData []byte) ([]byte, error)
+	}
+)
@@ -0,0 +1,20 @@
+package core
+
+import "context"
+
+type (
+	Notification struct {
+		UserID   int
+		Username string
+		// Address of the user on the notifier channel, empty if unknown
+		To      string
+		Subject string
+		Body    string
+	}
+
+	// Notifier returns ErrNoRecipient if its channel needs address
+	// that the notification does not have
+	Notifier interface {
+		Notify(ctx context.Context, notification Notification) error
+	}
+)
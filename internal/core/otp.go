@@ -0,0 +1,43 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// Channels one-time codes are sent by
+const (
+	OTPChannelEmail = "email"
+	OTPChannelSMS   = "sms"
+)
+
+type (
+	// OTPLogin is a one-time code sent to the user for login without password
+	OTPLogin struct {
+		ID        string
+		UserID    int
+		CodeHash  string
+		ExpiresAt time.Time
+	}
+
+	OTPConfig struct {
+		Length      int
+		TTL         time.Duration
+		MaxAttempts int
+	}
+
+	OTPService interface {
+		// StartOTPLogin sends code to the user by the channel, returned login id is
+		// passed with the code to CompleteOTPLogin. Unknown usernames are not reported
+		StartOTPLogin(ctx context.Context, username string, channel string) (loginID string, err error)
+		CompleteOTPLogin(ctx context.Context, loginID string, code string) (*LoginResult, error)
+	}
+
+	OTPStore interface {
+		// AddOTPLogin stores new code, previous codes of the user stop working
+		AddOTPLogin(ctx context.Context, login OTPLogin) error
+		// UseOTPLogin counts an attempt to enter the code and consumes login if
+		// code hash matches, login can not be used after maxAttempts attempts
+		UseOTPLogin(ctx context.Context, loginID string, codeHash string, maxAttempts int) (userID int, err error)
+	}
+)
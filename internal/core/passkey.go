@@ -0,0 +1,89 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// Purposes of passkey ceremonies
+const (
+	PasskeyRegistration = "registration"
+	PasskeyLogin        = "login"
+	PasskeyMFA          = "mfa"
+)
+
+type (
+	// PasskeyCredential is a WebAuthn public key credential of the user
+	PasskeyCredential struct {
+		ID              int
+		UserID          int
+		CredentialID    []byte
+		PublicKey       []byte
+		AttestationType string
+		AAGUID          []byte
+		SignCount       uint32
+		Transports      []string
+		BackupEligible  bool
+		BackupState     bool
+		Name            string
+		CreatedAt       time.Time
+		LastUsedAt      *time.Time
+	}
+
+	PasskeyUser struct {
+		ID          int
+		Name        string
+		Credentials []PasskeyCredential
+	}
+
+	// PasskeySession keeps state of a ceremony between its begin and finish,
+	// UserID is 0 for login with discoverable credential
+	PasskeySession struct {
+		ID        string
+		UserID    int
+		Purpose   string
+		Data      []byte
+		ExpiresAt time.Time
+	}
+
+	// PasskeyChallenge is sent to the client, options are json
+	// for navigator.credentials.create or navigator.credentials.get
+	PasskeyChallenge struct {
+		SessionID string
+		Options   []byte
+	}
+
+	PasskeyService interface {
+		// BeginPasskeyRegistration requires password, so stolen access token can not add a passkey
+		BeginPasskeyRegistration(ctx context.Context, userID int, password string) (*PasskeyChallenge, error)
+		FinishPasskeyRegistration(ctx context.Context, userID int, sessionID string, name string, response []byte) error
+		// BeginPasskeyLogin starts login with passkey as the only factor, or as
+		// the second one if mfa token is given. Without username and mfa token
+		// any discoverable credential is accepted
+		BeginPasskeyLogin(ctx context.Context, username string, mfaToken string) (*PasskeyChallenge, error)
+		FinishPasskeyLogin(ctx context.Context, sessionID string, response []byte) (*TokenPair, error)
+	}
+
+	PasskeyStore interface {
+		AddPasskeyCredential(ctx context.Context, credential PasskeyCredential) error
+		GetPasskeyCredentials(ctx context.Context, userID int) ([]PasskeyCredential, error)
+		// UpdatePasskeyCredential stores sign count and backup state after login
+		UpdatePasskeyCredential(ctx context.Context, credential PasskeyCredential) error
+		AddPasskeySession(ctx context.Context, session PasskeySession) error
+		// UsePasskeySession returns not expired session and deletes it
+		UsePasskeySession(ctx context.Context, sessionID string) (*PasskeySession, error)
+	}
+
+	// PasskeyRelyingParty runs WebAuthn ceremonies, options, sessions
+	// and responses are opaque json documents
+	PasskeyRelyingParty interface {
+		BeginRegistration(user PasskeyUser) (options []byte, session []byte, err error)
+		FinishRegistration(user PasskeyUser, session []byte, response []byte) (*PasskeyCredential, error)
+		// BeginLogin starts login of the user, nil user means discoverable credential,
+		// userVerification is required when passkey is the only factor
+		BeginLogin(user *PasskeyUser, userVerification bool) (options []byte, session []byte, err error)
+		// FinishLogin returns used credential with updated sign count,
+		// lookup returns owner of the credential by user id
+		FinishLogin(session []byte, response []byte, lookup func(userID int) (*PasskeyUser, error)) (*PasskeyCredential, error)
+	}
+)
@@ -0,0 +1,21 @@
+package core
+
+type (
+	// PasswordHasher hashes passwords into self-describing strings,
+	// algorithm and its parameters are stored with the hash
+	PasswordHasher interface {
+		Hash(password string) (string, error)
+		Verify(password string, hash string) error
+		// NeedsRehash reports whether hash was made with outdated algorithm or parameters
+		NeedsRehash(hash string) bool
+	}
+)
+
+type (
+	// Pepper is a server side secret mixed into password before hashing,
+	// version 0 means no pepper
+	Pepper interface {
+		CurrentVersion() int
+		Apply(version int, password string) (string, error)
+	}
+)
@@ -0,0 +1,32 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// PhoneVerification is a code sent by SMS to check that the user owns the phone
+	PhoneVerification struct {
+		UserID    int
+		Phone     string
+		CodeHash  string
+		ExpiresAt time.Time
+	}
+
+	PhoneService interface {
+		// SetPhone replaces phone of the user with unverified one and sends
+		// verification code to it, current password of the user is required
+		SetPhone(ctx context.Context, userID int, password string, phone string) error
+		// VerifyPhone marks the phone as verified if the code matches
+		VerifyPhone(ctx context.Context, userID int, code string) error
+	}
+
+	PhoneVerificationStore interface {
+		// AddPhoneVerification stores new code, previous code of the user stops working
+		AddPhoneVerification(ctx context.Context, verification PhoneVerification) error
+		// UsePhoneVerification counts an attempt to enter the code and consumes it if
+		// code hash matches, phone the code was sent to is returned
+		UsePhoneVerification(ctx context.Context, userID int, codeHash string, maxAttempts int) (phone string, err error)
+	}
+)
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"context"
+	"strings"
+)
+
+type (
+	PasswordPolicyConfig struct {
+		MinLength  int
+		MaxLength  int
+		MinClasses int
+		// Passwords seen in breaches at least this many times are rejected
+		BreachThreshold int
+		// HashScheme and Peppered describe new hashes, bcrypt ignores everything
+		// after 72 bytes unless the password is peppered into a short HMAC first
+		HashScheme string
+		Peppered   bool
+	}
+
+	// BreachedPasswordChecker reports how many times password was seen in known breaches
+	BreachedPasswordChecker interface {
+		BreachCount(ctx context.Context, password string) (int, error)
+	}
+
+	FieldViolation struct {
+		Field       string
+		Description string
+	}
+
+	// ValidationError lists every violated rule, so all of them can be shown at once
+	ValidationError struct {
+		Violations []FieldViolation
+	}
+)
+
+func (e *ValidationError) Error() string {
+	descriptions := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		descriptions = append(descriptions, violation.Field+": "+violation.Description)
+	}
+
+	return "validation failed: " + strings.Join(descriptions, "; ")
+}
@@ -0,0 +1,28 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// Keys of rate limits
+const (
+	RateLimitByIP       = "ip"
+	RateLimitByUsername = "username"
+	RateLimitByUser     = "user"
+)
+
+type (
+	// RateLimit allows Requests per Period, all of them may be used at once
+	RateLimit struct {
+		By       string
+		Requests int
+		Period   time.Duration
+	}
+
+	RateLimiter interface {
+		// Allow takes one request from the bucket of the key, retryAfter
+		// is the time until next request is allowed if this one is not
+		Allow(ctx context.Context, key string, limit RateLimit) (allowed bool, retryAfter time.Duration, err error)
+	}
+)
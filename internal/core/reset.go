@@ -0,0 +1,22 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	PasswordReset struct {
+		UserID    int
+		TokenHash string
+		ExpiresAt time.Time
+	}
+
+	PasswordResetStore interface {
+		AddPasswordReset(ctx context.Context, reset PasswordReset) error
+		GetPasswordReset(ctx context.Context, tokenHash string) (userID int, err error)
+		// UsePasswordReset consumes token of the user and sets its password at once,
+		// so the token stays usable if the password is not changed
+		UsePasswordReset(ctx context.Context, tokenHash string, user User) error
+	}
+)
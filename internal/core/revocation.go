@@ -0,0 +1,53 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// Uses of tokens signed with the same keys
+const (
+	TokenUseAccess            = "access"
+	TokenUseMFA               = "mfa"
+	TokenUseEmailVerification = "email"
+)
+
+type (
+	// Claims are the claims of access token
+	Claims struct {
+		ID         string
+		UserID     int
+		SessionID  string
+		Generation int
+		Issuer     string
+		Audience   []string
+		Roles      []string
+		// Permissions are granted by roles
+		Permissions []string
+		Scopes      []string
+		// Use tells what the token is for, only access tokens authorize calls
+		Use string
+		// Email is set only in access tokens of users with email
+		// and in email verification tokens
+		Email         string
+		EmailVerified bool
+		IssuedAt      time.Time
+		ExpiresAt     time.Time
+	}
+
+	RevocationService interface {
+		RevokeToken(ctx context.Context, claims Claims) error
+		RevokeAllTokens(ctx context.Context, userID int) error
+		// RevokeSession revokes every token of the session
+		RevokeSession(ctx context.Context, userID int, sessionID string) error
+		IsRevoked(ctx context.Context, claims Claims) (bool, error)
+		TokenGeneration(ctx context.Context, userID int) (int, error)
+	}
+
+	RevocationStore interface {
+		RevokeToken(ctx context.Context, tokenID string, userID int, expiresAt time.Time) error
+		IsTokenRevoked(ctx context.Context, tokenID string) (bool, error)
+		GetTokenGeneration(ctx context.Context, userID int) (generation int, err error)
+		IncrementTokenGeneration(ctx context.Context, userID int) (generation int, err error)
+	}
+)
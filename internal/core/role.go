@@ -0,0 +1,34 @@
+package core
+
+import "context"
+
+// Roles seeded by migrations
+const (
+	RoleListener  = "listener"
+	RoleProducer  = "producer"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Permissions seeded by migrations
+const (
+	PermissionAccountManage = "account:manage"
+	PermissionBeatsListen   = "beats:listen"
+	PermissionBeatsPublish  = "beats:publish"
+	PermissionBeatsModerate = "beats:moderate"
+	PermissionUsersRead     = "users:read"
+	PermissionUsersManage   = "users:manage"
+	PermissionRolesManage   = "roles:manage"
+	PermissionAuditRead     = "audit:read"
+)
+
+type (
+	RoleStore interface {
+		// GetUserRoles returns roles of the user and permissions granted by them
+		GetUserRoles(ctx context.Context, userID int) (roles []string, permissions []string, err error)
+		// AddUserRole assigns the role to the user, assigned role is not an error
+		AddUserRole(ctx context.Context, userID int, role string) error
+		// RemoveUserRole removes the role from the user, missing role is not an error
+		RemoveUserRole(ctx context.Context, userID int, role string) error
+	}
+)
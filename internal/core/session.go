@@ -0,0 +1,38 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// Session is a login on one device, it lives as long as its refresh token
+	// family, id of the family is the session id in access tokens
+	Session struct {
+		ID         string
+		UserID     int
+		DeviceName string
+		UserAgent  string
+		IP         string
+		CreatedAt  time.Time
+		LastSeenAt time.Time
+	}
+
+	SessionService interface {
+		ListSessions(ctx context.Context, userID int) ([]Session, error)
+		RevokeSession(ctx context.Context, userID int, sessionID string) error
+	}
+
+	SessionStore interface {
+		AddSession(ctx context.Context, session Session) error
+		// TouchSession updates last seen time and address of the session
+		TouchSession(ctx context.Context, sessionID string, client ClientInfo) error
+		// GetSessions returns sessions of the user that are not revoked
+		// and were seen after activeSince
+		GetSessions(ctx context.Context, userID int, activeSince time.Time) ([]Session, error)
+		RevokeSession(ctx context.Context, userID int, sessionID string) error
+		// RevokeUserSessions revokes all sessions of the user, except the session if it is not empty
+		RevokeUserSessions(ctx context.Context, userID int, exceptSessionID string) error
+		IsSessionRevoked(ctx context.Context, sessionID string) (bool, error)
+	}
+)
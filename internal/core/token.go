@@ -0,0 +1,31 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	TokenPair struct {
+		AccessToken  string
+		RefreshToken string
+	}
+
+	RefreshToken struct {
+		ID        int
+		UserID    int
+		TokenHash string
+		FamilyID  string
+		ExpiresAt time.Time
+		UsedAt    *time.Time
+		RevokedAt *time.Time
+	}
+
+	RefreshTokenStore interface {
+		AddRefreshToken(ctx context.Context, token RefreshToken) (tokenID int, err error)
+		GetRefreshToken(ctx context.Context, tokenHash string) (token *RefreshToken, err error)
+		UseRefreshToken(ctx context.Context, tokenID int) error
+		RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+		RevokeUserRefreshTokens(ctx context.Context, userID int, exceptFamilyID string) error
+	}
+)
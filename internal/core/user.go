@@ -1,17 +1,59 @@
 package core
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type (
 	User struct {
-		ID           int
-		Username     string
-		PasswordHash string
+		ID int
+		// Username is kept as entered by the user for display,
+		// CanonicalUsername is used to find the user
+		Username          string
+		CanonicalUsername string
+		PasswordHash      string
+		// Version of pepper applied before hashing, 0 if none
+		PepperVersion int
+		// Email is optional, it is stored normalized
+		Email         string
+		EmailVerified bool
+		// Phone is optional, it is stored in E.164 format
+		Phone         string
+		PhoneVerified bool
+		// Roles are assigned to the user when it is added
+		Roles []string
+		// Disabled users can not log in
+		Disabled bool
+		// PasswordResetRequired users can not log in with password until they reset it
+		PasswordResetRequired bool
+		CreatedAt             time.Time
 	}
 
 	UserStore interface {
 		AddUser(ctx context.Context, user User) (userID int, err error)
-		GetUserByUsername(ctx context.Context, username string) (user *User, err error)
+		GetUserByUsername(ctx context.Context, canonicalUsername string) (user *User, err error)
+		GetUserByEmail(ctx context.Context, email string) (user *User, err error)
+		GetUserByID(ctx context.Context, userID int) (user *User, err error)
+		// UpdateUser sets password of the user, required password reset is done by it
 		UpdateUser(ctx context.Context, user User) (userID int, err error)
+		// SetEmailVerified marks email as verified if the user still has it
+		SetEmailVerified(ctx context.Context, userID int, email string) error
+		// SetPhone sets unverified phone of the user, it returns ErrPhoneAlreadyExists
+		// if another user has the phone
+		SetPhone(ctx context.Context, userID int, phone string) error
+		// SetPhoneVerified marks phone as verified if the user still has it
+		SetPhoneVerified(ctx context.Context, userID int, phone string) error
+		// ListUsers returns users with roles whose id is greater than afterID,
+		// query is matched against canonical usernames and emails
+		ListUsers(ctx context.Context, query string, role string, afterID int, limit int) ([]User, error)
+		SetUserDisabled(ctx context.Context, userID int, disabled bool) error
+		SetPasswordResetRequired(ctx context.Context, userID int) error
+		// GetUsersWithoutCanonicalUsername and SetCanonicalUsername backfill
+		// canonical usernames of users created before them
+		GetUsersWithoutCanonicalUsername(ctx context.Context, limit int) ([]User, error)
+		// SetCanonicalUsername returns ErrUserAlreadyExists if the canonical username is taken
+		SetCanonicalUsername(ctx context.Context, user User) error
+		DeleteUser(ctx context.Context, userID int) error
 	}
 )
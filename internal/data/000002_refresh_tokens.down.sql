@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "refresh_tokens" CASCADE;
\ No newline at end of file
@@ -0,0 +1,10 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "token_generation" INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS "revoked_tokens" (
+    "jti" VARCHAR(64) PRIMARY KEY,
+    "user_id" INTEGER NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+    "expires_at" TIMESTAMPTZ NOT NULL,
+    "revoked_at" TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS "revoked_tokens_expires_at_idx" ON "revoked_tokens" ("expires_at");
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "users" DROP COLUMN IF EXISTS "pepper_version";
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "pepper_version" INTEGER NOT NULL DEFAULT 0;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "login_failures" CASCADE;
\ No newline at end of file
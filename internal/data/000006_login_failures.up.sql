@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS "login_failures" (
+    "scope" VARCHAR(16) NOT NULL,
+    "subject" VARCHAR(255) NOT NULL,
+    "failures" INTEGER NOT NULL DEFAULT 0,
+    "last_failed_at" TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    "locked_until" TIMESTAMPTZ,
+    PRIMARY KEY ("scope", "subject")
+);
\ No newline at end of file
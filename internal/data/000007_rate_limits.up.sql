@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS "rate_limit_buckets" (
+    "key" VARCHAR(512) PRIMARY KEY,
+    "tokens" DOUBLE PRECISION NOT NULL,
+    "updated_at" TIMESTAMPTZ NOT NULL,
+    "expires_at" TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "rate_limit_buckets_expires_at_idx" ON "rate_limit_buckets" ("expires_at");
\ No newline at end of file
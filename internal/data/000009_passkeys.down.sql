@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "passkey_sessions" CASCADE;
+
+DROP TABLE IF EXISTS "passkey_credentials" CASCADE;
\ No newline at end of file
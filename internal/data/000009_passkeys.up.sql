@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS "passkey_credentials" (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INTEGER NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+    "credential_id" BYTEA NOT NULL UNIQUE,
+    "public_key" BYTEA NOT NULL,
+    "attestation_type" VARCHAR(32) NOT NULL,
+    "aaguid" BYTEA,
+    "sign_count" BIGINT NOT NULL DEFAULT 0,
+    "transports" VARCHAR(255) NOT NULL DEFAULT '',
+    "backup_eligible" BOOLEAN NOT NULL DEFAULT FALSE,
+    "backup_state" BOOLEAN NOT NULL DEFAULT FALSE,
+    "name" VARCHAR(64) NOT NULL DEFAULT '',
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    "last_used_at" TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS "passkey_credentials_user_id_idx" ON "passkey_credentials" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "passkey_sessions" (
+    "id" VARCHAR(64) PRIMARY KEY,
+    "user_id" INTEGER REFERENCES "users" ("id") ON DELETE CASCADE,
+    "purpose" VARCHAR(16) NOT NULL,
+    "data" BYTEA NOT NULL,
+    "expires_at" TIMESTAMPTZ NOT NULL
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "otp_logins" CASCADE;
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS "otp_logins" (
+    "id" VARCHAR(64) PRIMARY KEY,
+    "user_id" INTEGER NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+    "code_hash" VARCHAR(64) NOT NULL,
+    "attempts" INTEGER NOT NULL DEFAULT 0,
+    "expires_at" TIMESTAMPTZ NOT NULL,
+    "used_at" TIMESTAMPTZ,
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS "otp_logins_user_id_idx" ON "otp_logins" ("user_id");
\ No newline at end of file
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "users_email_idx";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "email_verified_at";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "email";
\ No newline at end of file
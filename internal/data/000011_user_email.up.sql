@@ -0,0 +1,5 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "email" VARCHAR(255);
+
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "email_verified_at" TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "users_email_idx" ON "users" (LOWER("email"));
\ No newline at end of file
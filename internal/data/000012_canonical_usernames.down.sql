@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "users_username_canonical_idx";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "username_canonical";
\ No newline at end of file
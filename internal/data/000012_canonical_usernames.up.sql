@@ -0,0 +1,3 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "username_canonical" VARCHAR(255);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "users_username_canonical_idx" ON "users" ("username_canonical");
\ No newline at end of file
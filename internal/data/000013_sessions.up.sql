@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS "sessions" (
+    "id" VARCHAR(64) PRIMARY KEY,
+    "user_id" INTEGER NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+    "device_name" VARCHAR(64) NOT NULL DEFAULT '',
+    "user_agent" VARCHAR(255) NOT NULL DEFAULT '',
+    "ip" VARCHAR(45) NOT NULL DEFAULT '',
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    "last_seen_at" TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    "revoked_at" TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS "sessions_user_id_idx" ON "sessions" ("user_id");
\ No newline at end of file
@@ -0,0 +1,69 @@
+CREATE TABLE IF NOT EXISTS "roles" (
+    "id" SERIAL PRIMARY KEY,
+    "name" VARCHAR(64) NOT NULL UNIQUE,
+    "description" VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS "permissions" (
+    "id" SERIAL PRIMARY KEY,
+    "name" VARCHAR(64) NOT NULL UNIQUE,
+    "description" VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS "role_permissions" (
+    "role_id" INTEGER NOT NULL REFERENCES "roles" ("id") ON DELETE CASCADE,
+    "permission_id" INTEGER NOT NULL REFERENCES "permissions" ("id") ON DELETE CASCADE,
+    PRIMARY KEY ("role_id", "permission_id")
+);
+
+CREATE TABLE IF NOT EXISTS "user_roles" (
+    "user_id" INTEGER NOT NULL REFERENCES "users" ("id") ON DELETE CASCADE,
+    "role_id" INTEGER NOT NULL REFERENCES "roles" ("id") ON DELETE CASCADE,
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY ("user_id", "role_id")
+);
+
+INSERT INTO "roles" ("name", "description") VALUES
+    ('listener', 'Listens to beats and manages own account'),
+    ('producer', 'Uploads and sells beats'),
+    ('moderator', 'Moderates beats and comments'),
+    ('admin', 'Manages users and roles')
+ON CONFLICT ("name") DO NOTHING;
+
+INSERT INTO "permissions" ("name", "description") VALUES
+    ('account:manage', 'Manage own account, credentials and sessions'),
+    ('beats:listen', 'Listen to and buy beats'),
+    ('beats:publish', 'Upload and sell own beats'),
+    ('beats:moderate', 'Hide beats and comments of other users'),
+    ('users:read', 'View accounts of other users'),
+    ('users:manage', 'Disable, enable and delete accounts of other users'),
+    ('roles:manage', 'Assign and remove roles of users'),
+    ('audit:read', 'View security audit log')
+ON CONFLICT ("name") DO NOTHING;
+
+INSERT INTO "role_permissions" ("role_id", "permission_id")
+SELECT "roles"."id", "permissions"."id" FROM "roles", "permissions"
+WHERE ("roles"."name", "permissions"."name") IN (
+    ('listener', 'account:manage'),
+    ('listener', 'beats:listen'),
+    ('producer', 'account:manage'),
+    ('producer', 'beats:listen'),
+    ('producer', 'beats:publish'),
+    ('moderator', 'account:manage'),
+    ('moderator', 'beats:listen'),
+    ('moderator', 'beats:moderate'),
+    ('moderator', 'users:read'),
+    ('admin', 'account:manage'),
+    ('admin', 'beats:listen'),
+    ('admin', 'beats:moderate'),
+    ('admin', 'users:read'),
+    ('admin', 'users:manage'),
+    ('admin', 'roles:manage'),
+    ('admin', 'audit:read')
+)
+ON CONFLICT DO NOTHING;
+
+INSERT INTO "user_roles" ("user_id", "role_id")
+SELECT "users"."id", "roles"."id" FROM "users", "roles"
+WHERE "roles"."name" = 'listener'
+ON CONFLICT DO NOTHING;
\ No newline at end of file
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "audit_events";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "password_reset_required";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "disabled_at";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "created_at";
\ No newline at end of file
@@ -0,0 +1,17 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "disabled_at" TIMESTAMPTZ;
+
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "password_reset_required" BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS "audit_events" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "type" VARCHAR(64) NOT NULL,
+    "actor_id" INTEGER,
+    "target_id" INTEGER,
+    "ip" VARCHAR(45) NOT NULL DEFAULT '',
+    "user_agent" VARCHAR(255) NOT NULL DEFAULT '',
+    "outcome" VARCHAR(16) NOT NULL,
+    "details" VARCHAR(255) NOT NULL DEFAULT '',
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
\ No newline at end of file
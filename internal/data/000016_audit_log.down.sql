@@ -0,0 +1,13 @@
+DROP TRIGGER IF EXISTS "audit_events_append_only" ON "audit_events";
+
+DROP FUNCTION IF EXISTS "audit_events_append_only";
+
+DROP INDEX IF EXISTS "audit_events_type_idx";
+
+DROP INDEX IF EXISTS "audit_events_target_id_idx";
+
+DROP INDEX IF EXISTS "audit_events_actor_id_idx";
+
+DROP INDEX IF EXISTS "audit_events_created_at_idx";
+
+ALTER TABLE "audit_events" DROP COLUMN IF EXISTS "request_id";
\ No newline at end of file
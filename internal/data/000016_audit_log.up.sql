@@ -0,0 +1,20 @@
+ALTER TABLE "audit_events" ADD COLUMN IF NOT EXISTS "request_id" VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS "audit_events_created_at_idx" ON "audit_events" ("created_at");
+
+CREATE INDEX IF NOT EXISTS "audit_events_actor_id_idx" ON "audit_events" ("actor_id", "id");
+
+CREATE INDEX IF NOT EXISTS "audit_events_target_id_idx" ON "audit_events" ("target_id", "id");
+
+CREATE INDEX IF NOT EXISTS "audit_events_type_idx" ON "audit_events" ("type", "id");
+
+CREATE OR REPLACE FUNCTION "audit_events_append_only"() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit events can not be updated';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS "audit_events_append_only" ON "audit_events";
+
+CREATE TRIGGER "audit_events_append_only" BEFORE UPDATE ON "audit_events"
+FOR EACH ROW EXECUTE FUNCTION "audit_events_append_only"();
\ No newline at end of file
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "phone_verifications" CASCADE;
+
+DROP INDEX IF EXISTS "users_phone_idx";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "phone_verified_at";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "phone";
\ No newline at end of file
@@ -0,0 +1,14 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "phone" VARCHAR(16);
+
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "phone_verified_at" TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "users_phone_idx" ON "users" ("phone");
+
+CREATE TABLE IF NOT EXISTS "phone_verifications" (
+    "user_id" INTEGER PRIMARY KEY REFERENCES "users" ("id") ON DELETE CASCADE,
+    "phone" VARCHAR(16) NOT NULL,
+    "code_hash" VARCHAR(64) NOT NULL,
+    "attempts" INTEGER NOT NULL DEFAULT 0,
+    "expires_at" TIMESTAMPTZ NOT NULL,
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
\ No newline at end of file
@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/logger"
+	authv1 "github.com/MAXXXIMUS-tropical-milkshake/beatflow-protos/gen/go/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type adminServer struct {
+	authv1.UnimplementedAuthAdminServer
+	admin core.AdminService
+}
+
+func (s *adminServer) ListUsers(ctx context.Context, req *authv1.ListUsersRequest) (*authv1.ListUsersResponse, error) {
+	page, err := s.admin.ListUsers(ctx, core.UserFilter{
+		Query:     req.GetQuery(),
+		Role:      req.GetRole(),
+		PageSize:  int(req.GetPageSize()),
+		PageToken: req.GetPageToken(),
+	})
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, adminError(err, "failed to list users")
+	}
+
+	resp := &authv1.ListUsersResponse{
+		Users:         make([]*authv1.User, 0, len(page.Users)),
+		NextPageToken: page.NextPageToken,
+	}
+
+	for _, user := range page.Users {
+		resp.Users = append(resp.Users, userToProto(user))
+	}
+
+	return resp, nil
+}
+
+func (s *adminServer) GetUser(ctx context.Context, req *authv1.GetUserRequest) (*authv1.GetUserResponse, error) {
+	user, err := s.admin.GetUser(ctx, int(req.GetUserId()))
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, adminError(err, "failed to get user")
+	}
+
+	return &authv1.GetUserResponse{User: userToProto(*user)}, nil
+}
+
+func (s *adminServer) DisableUser(ctx context.Context, req *authv1.DisableUserRequest) (*authv1.DisableUserResponse, error) {
+	actorID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	err = s.admin.DisableUser(ctx, actorID, int(req.GetUserId()), req.GetReason())
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, adminError(err, "failed to disable user")
+	}
+
+	return &authv1.DisableUserResponse{}, nil
+}
+
+func (s *adminServer) EnableUser(ctx context.Context, req *authv1.EnableUserRequest) (*authv1.EnableUserResponse, error) {
+	actorID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	err = s.admin.EnableUser(ctx, actorID, int(req.GetUserId()))
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, adminError(err, "failed to enable user")
+	}
+
+	return &authv1.EnableUserResponse{}, nil
+}
+
+func (s *adminServer) ForcePasswordReset(ctx context.Context, req *authv1.ForcePasswordResetRequest) (*authv1.ForcePasswordResetResponse, error) {
+	actorID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	sent, err := s.admin.ForcePasswordReset(ctx, actorID, int(req.GetUserId()))
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, adminError(err, "failed to force password reset")
+	}
+
+	return &authv1.ForcePasswordResetResponse{ResetTokenSent: sent}, nil
+}
+
+func (s *adminServer) AssignRole(ctx context.Context, req *authv1.AssignRoleRequest) (*authv1.AssignRoleResponse, error) {
+	actorID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	err = s.admin.AssignRole(ctx, actorID, int(req.GetUserId()), req.GetRole())
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, adminError(err, "failed to assign role")
+	}
+
+	return &authv1.AssignRoleResponse{}, nil
+}
+
+func (s *adminServer) RemoveRole(ctx context.Context, req *authv1.RemoveRoleRequest) (*authv1.RemoveRoleResponse, error) {
+	actorID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	err = s.admin.RemoveRole(ctx, actorID, int(req.GetUserId()), req.GetRole())
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, adminError(err, "failed to remove role")
+	}
+
+	return &authv1.RemoveRoleResponse{}, nil
+}
+
+func (s *adminServer) DeleteUser(ctx context.Context, req *authv1.DeleteUserRequest) (*authv1.DeleteUserResponse, error) {
+	actorID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	err = s.admin.DeleteUser(ctx, actorID, int(req.GetUserId()))
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, adminError(err, "failed to delete user")
+	}
+
+	return &authv1.DeleteUserResponse{}, nil
+}
+
+func (s *adminServer) UnlockUser(ctx context.Context, req *authv1.UnlockUserRequest) (*authv1.UnlockUserResponse, error) {
+	actorID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	err = s.admin.UnlockUser(ctx, actorID, int(req.GetUserId()), strings.TrimSpace(req.GetIp()))
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, adminError(err, "failed to unlock user")
+	}
+
+	return &authv1.UnlockUserResponse{}, nil
+}
+
+func (s *adminServer) ListAuditEvents(ctx context.Context, req *authv1.ListAuditEventsRequest) (*authv1.ListAuditEventsResponse, error) {
+	filter := core.AuditFilter{
+		UserID:    int(req.GetUserId()),
+		Type:      req.GetType(),
+		PageSize:  int(req.GetPageSize()),
+		PageToken: req.GetPageToken(),
+	}
+
+	if req.GetSince() != 0 {
+		filter.Since = time.Unix(req.GetSince(), 0)
+	}
+
+	if req.GetUntil() != 0 {
+		filter.Until = time.Unix(req.GetUntil(), 0)
+	}
+
+	page, err := s.admin.ListAuditEvents(ctx, filter)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, adminError(err, "failed to list audit events")
+	}
+
+	resp := &authv1.ListAuditEventsResponse{
+		Events:        make([]*authv1.AuditEvent, 0, len(page.Events)),
+		NextPageToken: page.NextPageToken,
+	}
+
+	for _, event := range page.Events {
+		resp.Events = append(resp.Events, &authv1.AuditEvent{
+			Id:        event.ID,
+			Type:      event.Type,
+			ActorId:   int64(event.ActorID),
+			TargetId:  int64(event.TargetID),
+			Ip:        event.IP,
+			UserAgent: event.UserAgent,
+			RequestId: event.RequestID,
+			Outcome:   event.Outcome,
+			Details:   event.Details,
+			CreatedAt: event.CreatedAt.Unix(),
+		})
+	}
+
+	return resp, nil
+}
+
+// adminError converts errors of admin service to status, msg is used for unexpected errors
+func adminError(err error, msg string) error {
+	switch {
+	case errors.Is(err, core.ErrUserNotFound), errors.Is(err, core.ErrRoleNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, core.ErrSelfAdminAction):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, core.ErrInvalidPageToken):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return status.Error(codes.Internal, msg)
+}
+
+func userToProto(user core.User) *authv1.User {
+	return &authv1.User{
+		Id:                    int64(user.ID),
+		Username:              user.Username,
+		Email:                 user.Email,
+		EmailVerified:         user.EmailVerified,
+		Roles:                 user.Roles,
+		Disabled:              user.Disabled,
+		PasswordResetRequired: user.PasswordResetRequired,
+		CreatedAt:             user.CreatedAt.Unix(),
+	}
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+)
+
+// ServiceClients are credentials of other beatflow services,
+// only sha256 of client secrets is kept
+type ServiceClients struct {
+	secrets map[string][]byte
+}
+
+type serviceClientsFile struct {
+	Clients []struct {
+		ID           string `json:"id"`
+		SecretSHA256 string `json:"secret_sha256"`
+	} `json:"clients"`
+}
+
+// LoadServiceClients reads json file:
+//
+//	{"clients": [{"id": "beatflow-beats", "secret_sha256": "<hex>"}]}
+//
+// Empty path means that no service clients are allowed
+func LoadServiceClients(path string) (*ServiceClients, error) {
+	clients := &ServiceClients{secrets: map[string][]byte{}}
+	if path == "" {
+		return clients, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file serviceClientsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	for _, client := range file.Clients {
+		secret, err := hex.DecodeString(client.SecretSHA256)
+		if err != nil {
+			return nil, err
+		}
+
+		clients.secrets[client.ID] = secret
+	}
+
+	return clients, nil
+}
+
+func (c *ServiceClients) Authenticate(id string, secret string) bool {
+	expected, ok := c.secrets[id]
+	if !ok {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(secret))
+
+	return subtle.ConstantTimeCompare(sum[:], expected) == 1
+}
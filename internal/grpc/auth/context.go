@@ -3,5 +3,5 @@ package auth
 type contextKey string
 
 const (
-	userIDContextKey = contextKey("id")
+	serviceClientContextKey = contextKey("client")
 )
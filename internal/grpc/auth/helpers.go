@@ -2,45 +2,125 @@ package auth
 
 import (
 	"context"
+	"errors"
+	"math"
+	"strconv"
+	"time"
 
 	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/jwt"
 	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/logger"
-	"github.com/golang-jwt/jwt"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/pkg/authclient"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
-func validToken(ctx context.Context, tokenString string, secret string) (*int, error) {
-	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			logger.Log().Error(ctx, "unexpected signing method")
-			return nil, core.ErrUnauthorized
-		}
-
-		return []byte(secret), nil
-	})
+func validToken(ctx context.Context, tokenString string, verifier *jwt.Verifier) (*core.Claims, error) {
+	claims, err := verifier.Verify(tokenString)
 	if err != nil {
 		logger.Log().Debug(ctx, err.Error())
 		return nil, core.ErrUnauthorized
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		id, ok := claims["id"].(float64)
-		if !ok {
-			return nil, core.ErrUnauthorized
-		}
-
-		idInt := int(id)
-		return &idInt, nil
-	}
-
-	return nil, core.ErrUnauthorized
+	return claims, nil
 }
 
 func getUserIDFromContext(ctx context.Context) (int, error) {
-	id, ok := ctx.Value(userIDContextKey).(int)
+	principal, ok := authclient.FromContext(ctx)
 	if !ok {
 		logger.Log().Debug(ctx, "user id is not provided")
 		return 0, core.ErrUnauthorized
 	}
 
-	return id, nil
+	return principal.UserID, nil
+}
+
+func getClaimsFromContext(ctx context.Context) (*core.Claims, error) {
+	principal, ok := authclient.FromContext(ctx)
+	if !ok {
+		logger.Log().Debug(ctx, "claims are not provided")
+		return nil, core.ErrUnauthorized
+	}
+
+	return &core.Claims{
+		ID:          principal.TokenID,
+		UserID:      principal.UserID,
+		SessionID:   principal.SessionID,
+		Roles:       principal.Roles,
+		Permissions: principal.Permissions,
+		Scopes:      principal.Scopes,
+		ExpiresAt:   principal.ExpiresAt,
+	}, nil
+}
+
+// invalidArgument converts validation error to status with field violations
+func invalidArgument(err *core.ValidationError) error {
+	badRequest := &errdetails.BadRequest{}
+	for _, violation := range err.Violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       violation.Field,
+			Description: violation.Description,
+		})
+	}
+
+	st, detailsErr := status.New(codes.InvalidArgument, err.Error()).WithDetails(badRequest)
+	if detailsErr != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return st.Err()
+}
+
+// lockoutError converts rejected login to status, locked account gets
+// its own code and reason, so clients can tell it from a temporary delay
+func lockoutError(ctx context.Context, err *core.RetryError) error {
+	setRetryAfter(ctx, err.RetryAfter)
+
+	code, reason := codes.ResourceExhausted, "TOO_MANY_ATTEMPTS"
+	if errors.Is(err, core.ErrAccountLocked) {
+		code, reason = codes.PermissionDenied, "ACCOUNT_LOCKED"
+	}
+
+	st, detailsErr := status.New(code, err.Error()).WithDetails(
+		&errdetails.ErrorInfo{
+			Reason: reason,
+			Domain: "auth.beatflow",
+		},
+		&errdetails.RetryInfo{
+			RetryDelay: durationpb.New(err.RetryAfter),
+		},
+	)
+	if detailsErr != nil {
+		return status.Error(code, err.Error())
+	}
+
+	return st.Err()
+}
+
+// resourceExhausted returns status with retry delay
+func resourceExhausted(ctx context.Context, msg string, retryAfter time.Duration) error {
+	setRetryAfter(ctx, retryAfter)
+
+	st, detailsErr := status.New(codes.ResourceExhausted, msg).WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(retryAfter),
+	})
+	if detailsErr != nil {
+		return status.Error(codes.ResourceExhausted, msg)
+	}
+
+	return st.Err()
+}
+
+// setRetryAfter sends retry-after header in whole seconds, like http does
+func setRetryAfter(ctx context.Context, retryAfter time.Duration) {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+
+	err := grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.FormatInt(seconds, 10)))
+	if err != nil {
+		logger.Log().Debug(ctx, err.Error())
+	}
 }
@@ -2,19 +2,122 @@ package auth
 
 import (
 	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
 	"strings"
 
 	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/jwt"
 	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/logger"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/pkg/authclient"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
-func EnsureValidToken(secret string, requireAuth map[string]bool) grpc.UnaryServerInterceptor {
+// ClientInfo puts address, user agent and device name of the caller into context,
+// x-forwarded-for is trusted only when the service runs behind a proxy
+func ClientInfo(trustForwardedFor bool) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
-		if !requireAuth[info.FullMethod] {
+		var client core.ClientInfo
+
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			client.IP = hostIP(p.Addr.String())
+		}
+
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if userAgent := md.Get("user-agent"); len(userAgent) > 0 {
+				client.UserAgent = userAgent[0]
+			}
+
+			if deviceName := md.Get("x-device-name"); len(deviceName) > 0 {
+				client.DeviceName = strings.TrimSpace(deviceName[0])
+			}
+
+			if requestID := md.Get("x-request-id"); len(requestID) > 0 {
+				client.RequestID = strings.TrimSpace(requestID[0])
+			}
+
+			if forwardedFor := md.Get("x-forwarded-for"); trustForwardedFor && len(forwardedFor) > 0 {
+				// The last address is added by our proxy, the others are sent by the client
+				addresses := strings.Split(forwardedFor[len(forwardedFor)-1], ",")
+				if ip := strings.TrimSpace(addresses[len(addresses)-1]); ip != "" {
+					client.IP = ip
+				}
+			}
+		}
+
+		return handler(core.WithClientInfo(ctx, client), req)
+	}
+}
+
+// EnsureValidToken authenticates calls of methods that are not public or client ones
+// and checks permissions from the token, methods without policy are denied
+func EnsureValidToken(verifier *jwt.Verifier, revocation core.RevocationService, policies *MethodPolicies) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		policy, ok := policies.Policy(info.FullMethod)
+		if !ok {
+			logger.Log().Error(ctx, fmt.Sprintf("method %s has no policy", info.FullMethod))
+			return nil, status.Error(codes.PermissionDenied, "permission denied")
+		}
+
+		if policy.Public || policy.Client {
+			return handler(ctx, req)
+		}
+
+		tokenString, ok := authclient.TokenFromContext(ctx)
+		if !ok {
+			logger.Log().Debug(ctx, "token is not provided")
+			return nil, status.Error(codes.Unauthenticated, core.ErrUnauthorized.Error())
+		}
+
+		claims, err := validToken(ctx, tokenString, verifier)
+		if err != nil {
+			logger.Log().Debug(ctx, err.Error())
+			return nil, status.Error(codes.Unauthenticated, core.ErrUnauthorized.Error())
+		}
+
+		revoked, err := revocation.IsRevoked(ctx, *claims)
+		if err != nil {
+			logger.Log().Error(ctx, err.Error())
+			return nil, status.Error(codes.Internal, "failed to check token")
+		}
+
+		if revoked {
+			logger.Log().Debug(ctx, "token is revoked")
+			return nil, status.Error(codes.Unauthenticated, core.ErrUnauthorized.Error())
+		}
+
+		if !policy.Allows(claims.Permissions) {
+			logger.Log().Debug(ctx, "permissions of %s are missing", info.FullMethod)
+			return nil, status.Error(codes.PermissionDenied, "permission denied")
+		}
+
+		ctx = authclient.NewContext(ctx, &authclient.Principal{
+			UserID:      claims.UserID,
+			SessionID:   claims.SessionID,
+			TokenID:     claims.ID,
+			Roles:       claims.Roles,
+			Permissions: claims.Permissions,
+			Scopes:      claims.Scopes,
+			ExpiresAt:   claims.ExpiresAt,
+
+			Email:         claims.Email,
+			EmailVerified: claims.EmailVerified,
+		})
+
+		return handler(ctx, req)
+	}
+}
+
+// EnsureServiceClient checks credentials of service clients for methods with client access,
+// they are sent as basic authorization: base64(id:secret)
+func EnsureServiceClient(clients *ServiceClients, policies *MethodPolicies) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		if policy, ok := policies.Policy(info.FullMethod); !ok || !policy.Client {
 			return handler(ctx, req)
 		}
 
@@ -25,22 +128,34 @@ func EnsureValidToken(secret string, requireAuth map[string]bool) grpc.UnaryServ
 		}
 
 		authorization := md.Get("authorization")
-		if len(authorization) == 0 {
-			logger.Log().Debug(ctx, "token is not provided")
+		if len(authorization) == 0 || !strings.HasPrefix(authorization[0], "Basic ") {
+			logger.Log().Debug(ctx, "client credentials are not provided")
 			return nil, status.Error(codes.Unauthenticated, core.ErrUnauthorized.Error())
 		}
 
-		tokenString := strings.TrimPrefix(authorization[0], "Bearer")
-		tokenString = strings.TrimSpace(tokenString)
-
-		id, err := validToken(ctx, tokenString, secret)
+		credentials, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(authorization[0], "Basic "))
 		if err != nil {
 			logger.Log().Debug(ctx, err.Error())
 			return nil, status.Error(codes.Unauthenticated, core.ErrUnauthorized.Error())
 		}
 
-		ctx = context.WithValue(ctx, userIDContextKey, *id)
+		id, secret, ok := strings.Cut(string(credentials), ":")
+		if !ok || !clients.Authenticate(id, secret) {
+			logger.Log().Debug(ctx, "invalid client credentials")
+			return nil, status.Error(codes.Unauthenticated, core.ErrUnauthorized.Error())
+		}
+
+		ctx = context.WithValue(ctx, serviceClientContextKey, id)
 
 		return handler(ctx, req)
 	}
 }
+
+func hostIP(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	return host
+}
@@ -0,0 +1,205 @@
+package auth
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc"
+)
+
+// Access of methods that do not require permissions
+const (
+	AccessPublic        = "public"
+	AccessAuthenticated = "authenticated"
+	AccessClient        = "client"
+)
+
+//go:embed method_policies.json
+var defaultMethodPolicies []byte
+
+// MethodPolicy describes who may call a method
+type MethodPolicy struct {
+	// Public methods are called without token
+	Public bool
+	// Client methods are called by service clients with their credentials instead of token
+	Client bool
+	// Permissions are alternatives, the caller needs all permissions of any of them.
+	// Any authenticated user may call the method if empty
+	Permissions [][]string
+}
+
+// Allows reports whether caller with permissions satisfies the policy
+func (p MethodPolicy) Allows(permissions []string) bool {
+	if p.Public || p.Client || len(p.Permissions) == 0 {
+		return true
+	}
+
+	for _, required := range p.Permissions {
+		allowed := true
+		for _, permission := range required {
+			if !slices.Contains(permissions, permission) {
+				allowed = false
+				break
+			}
+		}
+
+		if allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MethodPolicies finds policies of methods by full name or glob
+type MethodPolicies struct {
+	exact map[string]MethodPolicy
+	globs []methodGlob
+}
+
+type methodGlob struct {
+	pattern string
+	policy  MethodPolicy
+}
+
+// Policy returns policy of the method, full names take precedence over globs
+// and globs are tried in the order of the file
+func (p *MethodPolicies) Policy(method string) (MethodPolicy, bool) {
+	if policy, ok := p.exact[method]; ok {
+		return policy, true
+	}
+
+	for _, glob := range p.globs {
+		if ok, _ := path.Match(glob.pattern, method); ok {
+			return glob.policy, true
+		}
+	}
+
+	return MethodPolicy{}, false
+}
+
+// Check returns error if any method registered on the server has no policy,
+// such method would be denied to everyone
+func (p *MethodPolicies) Check(server *grpc.Server) error {
+	var missing []string
+	for service, info := range server.GetServiceInfo() {
+		for _, method := range info.Methods {
+			fullMethod := "/" + service + "/" + method.Name
+			if _, ok := p.Policy(fullMethod); !ok {
+				missing = append(missing, fullMethod)
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("methods have no policy: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+type methodPoliciesFile struct {
+	Policies []struct {
+		Method string `json:"method"`
+		Access string `json:"access"`
+	} `json:"policies"`
+}
+
+// LoadMethodPolicies reads json file:
+//
+//	{"policies": [
+//		{"method": "/auth.Auth/Login", "access": "public"},
+//		{"method": "/auth.Auth/*", "access": "authenticated"},
+//		{"method": "/auth.Admin/ListUsers", "access": "users:read || users:manage"}
+//	]}
+//
+// method is full method name or glob, access is public, authenticated,
+// client or permission expression where && binds tighter than ||.
+// Client methods require credentials of a service client instead of token.
+// Empty path means that embedded default policies are used
+func LoadMethodPolicies(filePath string) (*MethodPolicies, error) {
+	data := defaultMethodPolicies
+	if filePath != "" {
+		var err error
+		data, err = os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var file methodPoliciesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	policies := &MethodPolicies{exact: make(map[string]MethodPolicy)}
+	for _, entry := range file.Policies {
+		if !strings.HasPrefix(entry.Method, "/") {
+			return nil, fmt.Errorf("method %q must be full method name", entry.Method)
+		}
+
+		if _, err := path.Match(entry.Method, ""); err != nil {
+			return nil, fmt.Errorf("invalid method glob %q: %w", entry.Method, err)
+		}
+
+		policy, err := parseAccess(entry.Access)
+		if err != nil {
+			return nil, fmt.Errorf("invalid access of %s: %w", entry.Method, err)
+		}
+
+		if !strings.ContainsAny(entry.Method, `*?[\`) {
+			if _, ok := policies.exact[entry.Method]; ok {
+				return nil, fmt.Errorf("duplicate policy of %s", entry.Method)
+			}
+
+			policies.exact[entry.Method] = policy
+			continue
+		}
+
+		policies.globs = append(policies.globs, methodGlob{
+			pattern: entry.Method,
+			policy:  policy,
+		})
+	}
+
+	return policies, nil
+}
+
+func parseAccess(access string) (MethodPolicy, error) {
+	access = strings.TrimSpace(access)
+
+	switch access {
+	case AccessPublic:
+		return MethodPolicy{Public: true}, nil
+	case AccessAuthenticated:
+		return MethodPolicy{}, nil
+	case AccessClient:
+		return MethodPolicy{Client: true}, nil
+	case "":
+		return MethodPolicy{}, fmt.Errorf("access is empty")
+	}
+
+	var alternatives [][]string
+	for _, alternative := range strings.Split(access, "||") {
+		var required []string
+		for _, permission := range strings.Split(alternative, "&&") {
+			permission = strings.TrimSpace(permission)
+			if permission == "" || strings.ContainsAny(permission, " \t()!|&") {
+				return MethodPolicy{}, fmt.Errorf("invalid permission %q in %q", permission, access)
+			}
+
+			required = append(required, permission)
+		}
+
+		alternatives = append(alternatives, required)
+	}
+
+	return MethodPolicy{Permissions: alternatives}, nil
+}
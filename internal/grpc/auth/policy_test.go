@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func loadPolicies(t *testing.T, data string) *MethodPolicies {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "policies.json")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("write policies: %v", err)
+	}
+
+	policies, err := LoadMethodPolicies(path)
+	if err != nil {
+		t.Fatalf("LoadMethodPolicies: %v", err)
+	}
+
+	return policies
+}
+
+func TestParseAccessPrecedence(t *testing.T) {
+	policy, err := parseAccess("users:read && audit:read || users:manage")
+	if err != nil {
+		t.Fatalf("parseAccess: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		permissions []string
+		want        bool
+	}{
+		{"both of first alternative", []string{"users:read", "audit:read"}, true},
+		{"second alternative", []string{"users:manage"}, true},
+		{"one of first alternative", []string{"users:read"}, false},
+		{"other of first alternative", []string{"audit:read"}, false},
+		{"none", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Allows(tt.permissions); got != tt.want {
+				t.Errorf("Allows(%v) = %v, want %v", tt.permissions, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAccessInvalid(t *testing.T) {
+	for _, access := range []string{"", "users:read &&", "|| users:read", "(users:read)", "!users:read", "users read"} {
+		if _, err := parseAccess(access); err == nil {
+			t.Errorf("parseAccess(%q) accepted invalid access", access)
+		}
+	}
+}
+
+func TestPolicyGlobPrecedence(t *testing.T) {
+	policies := loadPolicies(t, `{"policies": [
+		{"method": "/auth.Admin/*", "access": "users:manage"},
+		{"method": "/auth.Admin/List*", "access": "users:read"},
+		{"method": "/auth.Admin/ListAudit", "access": "audit:read"}
+	]}`)
+
+	tests := []struct {
+		method     string
+		permission string
+	}{
+		// Full name takes precedence over globs
+		{"/auth.Admin/ListAudit", "audit:read"},
+		// Globs are tried in the order of the file, so the first one wins
+		{"/auth.Admin/ListUsers", "users:manage"},
+		{"/auth.Admin/DeleteUser", "users:manage"},
+	}
+
+	for _, tt := range tests {
+		policy, ok := policies.Policy(tt.method)
+		if !ok {
+			t.Fatalf("no policy of %s", tt.method)
+		}
+
+		if !policy.Allows([]string{tt.permission}) {
+			t.Errorf("policy of %s does not allow %s: %+v", tt.method, tt.permission, policy)
+		}
+	}
+}
+
+func TestLoadMethodPoliciesInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"relative method", `{"policies": [{"method": "auth.Auth/Login", "access": "public"}]}`},
+		{"invalid glob", `{"policies": [{"method": "/auth.Auth/[", "access": "public"}]}`},
+		{"duplicate", `{"policies": [
+			{"method": "/auth.Auth/Login", "access": "public"},
+			{"method": "/auth.Auth/Login", "access": "authenticated"}
+		]}`},
+		{"empty access", `{"policies": [{"method": "/auth.Auth/Login", "access": ""}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "policies.json")
+			if err := os.WriteFile(path, []byte(tt.data), 0o600); err != nil {
+				t.Fatalf("write policies: %v", err)
+			}
+
+			if _, err := LoadMethodPolicies(path); err == nil {
+				t.Error("LoadMethodPolicies accepted invalid policies")
+			}
+		})
+	}
+}
+
+func TestEnsureValidTokenDeniesUnknownMethod(t *testing.T) {
+	logger.New("error")
+
+	policies := loadPolicies(t, `{"policies": [{"method": "/auth.Auth/Login", "access": "public"}]}`)
+	interceptor := EnsureValidToken(nil, nil, policies)
+
+	called := false
+	handler := func(ctx context.Context, req any) (any, error) {
+		called = true
+		return nil, nil
+	}
+
+	_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/auth.Auth/Unknown"}, handler)
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("got %v, want %v", err, codes.PermissionDenied)
+	}
+
+	if called {
+		t.Error("handler of unknown method was called")
+	}
+}
+
+func TestCheckDefaultPolicies(t *testing.T) {
+	server := grpc.NewServer()
+	Register(server, nil)
+
+	policies, err := LoadMethodPolicies("")
+	if err != nil {
+		t.Fatalf("LoadMethodPolicies: %v", err)
+	}
+
+	if err := policies.Check(server); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+}
+
+func TestCheckUnlistedMethod(t *testing.T) {
+	server := grpc.NewServer()
+	Register(server, nil)
+
+	policies := loadPolicies(t, `{"policies": [
+		{"method": "/auth.Auth/*", "access": "authenticated"},
+		{"method": "/auth.AuthAdmin/ListUsers", "access": "users:manage"}
+	]}`)
+
+	err := policies.Check(server)
+	if err == nil {
+		t.Fatal("Check accepted methods without policy")
+	}
+
+	if !strings.Contains(err.Error(), "/auth.AuthAdmin/DeleteUser") {
+		t.Errorf("error does not name unlisted method: %v", err)
+	}
+
+	if strings.Contains(err.Error(), "/auth.AuthAdmin/ListUsers") || strings.Contains(err.Error(), "/auth.Auth/Login") {
+		t.Errorf("error names method with policy: %v", err)
+	}
+}
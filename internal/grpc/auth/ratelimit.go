@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/canonical"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/logger"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/pkg/authclient"
+	"google.golang.org/grpc"
+)
+
+type usernameRequest interface {
+	GetUsername() string
+}
+
+// RateLimit limits calls of methods by client ip, username from request
+// or authenticated user. Calls are allowed when limiter fails,
+// so its outage does not take the service down
+func RateLimit(limiter core.RateLimiter, limits map[string][]core.RateLimit) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		for _, limit := range limits[info.FullMethod] {
+			subject := rateLimitSubject(ctx, req, limit.By)
+			if subject == "" {
+				continue
+			}
+
+			key := info.FullMethod + ":" + limit.By + ":" + subject
+
+			allowed, retryAfter, err := limiter.Allow(ctx, key, limit)
+			if err != nil {
+				logger.Log().Error(ctx, err.Error())
+				continue
+			}
+
+			if !allowed {
+				logger.Log().Debug(ctx, "rate limit exceeded: %s", key)
+				return nil, resourceExhausted(ctx, "rate limit exceeded", retryAfter)
+			}
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func rateLimitSubject(ctx context.Context, req any, by string) string {
+	switch by {
+	case core.RateLimitByIP:
+		return core.ClientInfoFromContext(ctx).IP
+	case core.RateLimitByUsername:
+		if r, ok := req.(usernameRequest); ok {
+			return canonical.Username(r.GetUsername())
+		}
+	case core.RateLimitByUser:
+		if principal, ok := authclient.FromContext(ctx); ok {
+			return fmt.Sprint(principal.UserID)
+		}
+	}
+
+	return ""
+}
+
+type rateLimitsFile struct {
+	Limits []struct {
+		Method   string `json:"method"`
+		By       string `json:"by"`
+		Requests int    `json:"requests"`
+		Period   string `json:"period"`
+	} `json:"limits"`
+}
+
+// LoadRateLimits reads json file:
+//
+//	{"limits": [{"method": "/auth.Auth/Login", "by": "ip", "requests": 20, "period": "1m"}]}
+//
+// by is one of ip, username or user
+func LoadRateLimits(path string) (map[string][]core.RateLimit, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file rateLimitsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	limits := make(map[string][]core.RateLimit)
+	for _, limit := range file.Limits {
+		switch limit.By {
+		case core.RateLimitByIP, core.RateLimitByUsername, core.RateLimitByUser:
+		default:
+			return nil, fmt.Errorf("unknown rate limit key %q of %s", limit.By, limit.Method)
+		}
+
+		period, err := time.ParseDuration(limit.Period)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit period of %s: %w", limit.Method, err)
+		}
+
+		if limit.Requests <= 0 || period <= 0 {
+			return nil, fmt.Errorf("rate limit of %s must allow requests in positive period", limit.Method)
+		}
+
+		limits[limit.Method] = append(limits[limit.Method], core.RateLimit{
+			By:       limit.By,
+			Requests: limit.Requests,
+			Period:   period,
+		})
+	}
+
+	return limits, nil
+}
@@ -3,6 +3,8 @@ package auth
 import (
 	"context"
 	"errors"
+	"strconv"
+	"strings"
 
 	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
 	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/logger"
@@ -19,6 +21,7 @@ type server struct {
 
 func Register(gRPCServer *grpc.Server, auth core.AuthService) {
 	authv1.RegisterAuthServer(gRPCServer, &server{auth: auth})
+	authv1.RegisterAuthAdminServer(gRPCServer, &adminServer{admin: auth})
 }
 
 func (s *server) Login(ctx context.Context, req *authv1.LoginRequest) (*authv1.LoginResponse, error) {
@@ -27,28 +30,53 @@ func (s *server) Login(ctx context.Context, req *authv1.LoginRequest) (*authv1.L
 		PasswordHash: req.GetPassword(),
 	}
 
-	token, err := s.auth.Login(ctx, user)
+	result, err := s.auth.Login(ctx, user)
 	if err != nil {
 		logger.Log().Error(ctx, err.Error())
+		var retryErr *core.RetryError
+		if errors.As(err, &retryErr) {
+			return nil, lockoutError(ctx, retryErr)
+		}
 		if errors.Is(err, core.ErrInvalidCredentials) {
 			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
+		if errors.Is(err, core.ErrEmailNotVerified) || errors.Is(err, core.ErrAccountDisabled) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		if errors.Is(err, core.ErrPasswordResetRequired) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
 		return nil, status.Error(codes.Internal, "failed to login")
 	}
 
-	return &authv1.LoginResponse{Token: *token}, nil
+	if result.MFAToken != "" {
+		return &authv1.LoginResponse{
+			MfaRequired: true,
+			MfaToken:    result.MFAToken,
+		}, nil
+	}
+
+	return &authv1.LoginResponse{
+		Token:        result.Tokens.AccessToken,
+		RefreshToken: result.Tokens.RefreshToken,
+	}, nil
 }
 
 func (s *server) Signup(ctx context.Context, req *authv1.SignupRequest) (*authv1.SignupResponse, error) {
 	user := core.User{
 		Username:     req.GetUsername(),
 		PasswordHash: req.GetPassword(),
+		Email:        req.GetEmail(),
 	}
 
 	err := s.auth.Signup(ctx, user)
 	if err != nil {
 		logger.Log().Error(ctx, err.Error())
-		if errors.Is(err, core.ErrUserAlreadyExists) {
+		var validationErr *core.ValidationError
+		if errors.As(err, &validationErr) {
+			return nil, invalidArgument(validationErr)
+		}
+		if errors.Is(err, core.ErrUserAlreadyExists) || errors.Is(err, core.ErrEmailAlreadyExists) {
 			return nil, status.Error(codes.AlreadyExists, err.Error())
 		}
 		return nil, status.Error(codes.Internal, "failed to signup")
@@ -58,22 +86,514 @@ func (s *server) Signup(ctx context.Context, req *authv1.SignupRequest) (*authv1
 }
 
 func (s *server) UpdatePassword(ctx context.Context, req *authv1.UpdatePasswordRequest) (*authv1.UpdatePasswordResponse, error) {
+	claims, err := getClaimsFromContext(ctx)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	token, err := s.auth.UpdatePassword(ctx, *claims, req.GetOldPassword(), req.GetNewPassword())
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		var validationErr *core.ValidationError
+		if errors.As(err, &validationErr) {
+			return nil, invalidArgument(validationErr)
+		}
+		if errors.Is(err, core.ErrInvalidCredentials) || errors.Is(err, core.ErrSamePassword) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to update password")
+	}
+
+	return &authv1.UpdatePasswordResponse{Token: *token}, nil
+}
+
+func (s *server) Refresh(ctx context.Context, req *authv1.RefreshRequest) (*authv1.RefreshResponse, error) {
+	tokens, err := s.auth.Refresh(ctx, req.GetRefreshToken())
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		if errors.Is(err, core.ErrInvalidRefreshToken) || errors.Is(err, core.ErrRefreshTokenReused) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if errors.Is(err, core.ErrEmailNotVerified) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to refresh token")
+	}
+
+	return &authv1.RefreshResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+func (s *server) Logout(ctx context.Context, req *authv1.LogoutRequest) (*authv1.LogoutResponse, error) {
+	claims, err := getClaimsFromContext(ctx)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	err = s.auth.Logout(ctx, *claims)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, status.Error(codes.Internal, "failed to logout")
+	}
+
+	return &authv1.LogoutResponse{}, nil
+}
+
+func (s *server) LogoutAll(ctx context.Context, req *authv1.LogoutAllRequest) (*authv1.LogoutAllResponse, error) {
 	userID, err := getUserIDFromContext(ctx)
 	if err != nil {
 		logger.Log().Error(ctx, err.Error())
 		return nil, status.Error(codes.Unauthenticated, err.Error())
 	}
 
-	user := core.User{
-		ID:           userID,
-		PasswordHash: req.GetNewPassword(),
+	err = s.auth.LogoutAll(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, status.Error(codes.Internal, "failed to logout")
 	}
 
-	err = s.auth.UpdatePassword(ctx, user)
+	return &authv1.LogoutAllResponse{}, nil
+}
+
+func (s *server) GetJWKS(ctx context.Context, req *authv1.GetJWKSRequest) (*authv1.GetJWKSResponse, error) {
+	keys := s.auth.GetJWKS(ctx)
+
+	resp := &authv1.GetJWKSResponse{Keys: make([]*authv1.JWK, 0, len(keys))}
+	for _, key := range keys {
+		resp.Keys = append(resp.Keys, &authv1.JWK{
+			Kty: key.Kty,
+			Kid: key.Kid,
+			Use: key.Use,
+			Alg: key.Alg,
+			N:   key.N,
+			E:   key.E,
+			Crv: key.Crv,
+			X:   key.X,
+			Y:   key.Y,
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *server) Introspect(ctx context.Context, req *authv1.IntrospectRequest) (*authv1.IntrospectResponse, error) {
+	claims, err := s.auth.Introspect(ctx, req.GetToken())
 	if err != nil {
+		if errors.Is(err, core.ErrInactiveToken) {
+			return &authv1.IntrospectResponse{Active: false}, nil
+		}
 		logger.Log().Error(ctx, err.Error())
-		return nil, status.Error(codes.Internal, "failed to update password")
+		return nil, status.Error(codes.Internal, "failed to introspect token")
+	}
+
+	return &authv1.IntrospectResponse{
+		Active:      true,
+		Sub:         strconv.Itoa(claims.UserID),
+		Scope:       strings.Join(claims.Scopes, " "),
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+		Exp:         claims.ExpiresAt.Unix(),
+		Iat:         claims.IssuedAt.Unix(),
+		Sid:         claims.SessionID,
+		Iss:         claims.Issuer,
+		Aud:         claims.Audience,
+		Jti:         claims.ID,
+
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+	}, nil
+}
+
+func (s *server) RequestPasswordReset(ctx context.Context, req *authv1.RequestPasswordResetRequest) (*authv1.RequestPasswordResetResponse, error) {
+	err := s.auth.RequestPasswordReset(ctx, req.GetUsername())
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, status.Error(codes.Internal, "failed to request password reset")
+	}
+
+	return &authv1.RequestPasswordResetResponse{}, nil
+}
+
+func (s *server) ConfirmPasswordReset(ctx context.Context, req *authv1.ConfirmPasswordResetRequest) (*authv1.ConfirmPasswordResetResponse, error) {
+	err := s.auth.ConfirmPasswordReset(ctx, req.GetToken(), req.GetNewPassword())
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		var validationErr *core.ValidationError
+		if errors.As(err, &validationErr) {
+			return nil, invalidArgument(validationErr)
+		}
+		if errors.Is(err, core.ErrInvalidResetToken) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to reset password")
+	}
+
+	return &authv1.ConfirmPasswordResetResponse{}, nil
+}
+
+func (s *server) EnrollTOTP(ctx context.Context, req *authv1.EnrollTOTPRequest) (*authv1.EnrollTOTPResponse, error) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	enrollment, err := s.auth.EnrollTOTP(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		if errors.Is(err, core.ErrMFAAlreadyEnabled) || errors.Is(err, core.ErrMFANotConfigured) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to enroll totp")
+	}
+
+	return &authv1.EnrollTOTPResponse{
+		Secret: enrollment.Secret,
+		Uri:    enrollment.URI,
+	}, nil
+}
+
+func (s *server) ConfirmTOTP(ctx context.Context, req *authv1.ConfirmTOTPRequest) (*authv1.ConfirmTOTPResponse, error) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	recoveryCodes, err := s.auth.ConfirmTOTP(ctx, userID, req.GetCode())
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		if errors.Is(err, core.ErrInvalidMFACode) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, core.ErrMFAAlreadyEnabled) || errors.Is(err, core.ErrMFANotEnrolled) ||
+			errors.Is(err, core.ErrMFANotConfigured) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to confirm totp")
+	}
+
+	return &authv1.ConfirmTOTPResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+func (s *server) DisableTOTP(ctx context.Context, req *authv1.DisableTOTPRequest) (*authv1.DisableTOTPResponse, error) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	err = s.auth.DisableTOTP(ctx, userID, req.GetPassword())
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		if errors.Is(err, core.ErrInvalidCredentials) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to disable totp")
+	}
+
+	return &authv1.DisableTOTPResponse{}, nil
+}
+
+func (s *server) VerifyMFA(ctx context.Context, req *authv1.VerifyMFARequest) (*authv1.VerifyMFAResponse, error) {
+	tokens, err := s.auth.VerifyMFA(ctx, req.GetMfaToken(), req.GetCode(), req.GetRecoveryCode())
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		var retryErr *core.RetryError
+		if errors.As(err, &retryErr) {
+			return nil, lockoutError(ctx, retryErr)
+		}
+		if errors.Is(err, core.ErrInvalidMFAToken) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if errors.Is(err, core.ErrInvalidMFACode) || errors.Is(err, core.ErrMFANotEnrolled) {
+			return nil, status.Error(codes.InvalidArgument, core.ErrInvalidMFACode.Error())
+		}
+		if errors.Is(err, core.ErrEmailNotVerified) || errors.Is(err, core.ErrAccountDisabled) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to verify mfa")
+	}
+
+	return &authv1.VerifyMFAResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+func (s *server) BeginPasskeyRegistration(ctx context.Context, req *authv1.BeginPasskeyRegistrationRequest) (*authv1.BeginPasskeyRegistrationResponse, error) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	challenge, err := s.auth.BeginPasskeyRegistration(ctx, userID, req.GetPassword())
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		if errors.Is(err, core.ErrInvalidCredentials) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, core.ErrPasskeysNotConfigured) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to begin passkey registration")
+	}
+
+	return &authv1.BeginPasskeyRegistrationResponse{
+		SessionId: challenge.SessionID,
+		Options:   string(challenge.Options),
+	}, nil
+}
+
+func (s *server) FinishPasskeyRegistration(ctx context.Context, req *authv1.FinishPasskeyRegistrationRequest) (*authv1.FinishPasskeyRegistrationResponse, error) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	err = s.auth.FinishPasskeyRegistration(ctx, userID, req.GetSessionId(), req.GetName(), []byte(req.GetCredential()))
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		if errors.Is(err, core.ErrInvalidPasskeySession) || errors.Is(err, core.ErrInvalidPasskeyResponse) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, core.ErrPasskeysNotConfigured) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to finish passkey registration")
+	}
+
+	return &authv1.FinishPasskeyRegistrationResponse{}, nil
+}
+
+func (s *server) BeginPasskeyLogin(ctx context.Context, req *authv1.BeginPasskeyLoginRequest) (*authv1.BeginPasskeyLoginResponse, error) {
+	challenge, err := s.auth.BeginPasskeyLogin(ctx, req.GetUsername(), req.GetMfaToken())
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		if errors.Is(err, core.ErrInvalidMFAToken) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if errors.Is(err, core.ErrInvalidCredentials) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, core.ErrPasskeysNotConfigured) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to begin passkey login")
+	}
+
+	return &authv1.BeginPasskeyLoginResponse{
+		SessionId: challenge.SessionID,
+		Options:   string(challenge.Options),
+	}, nil
+}
+
+func (s *server) FinishPasskeyLogin(ctx context.Context, req *authv1.FinishPasskeyLoginRequest) (*authv1.FinishPasskeyLoginResponse, error) {
+	tokens, err := s.auth.FinishPasskeyLogin(ctx, req.GetSessionId(), []byte(req.GetCredential()))
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		var retryErr *core.RetryError
+		if errors.As(err, &retryErr) {
+			return nil, lockoutError(ctx, retryErr)
+		}
+		if errors.Is(err, core.ErrInvalidPasskeySession) || errors.Is(err, core.ErrInvalidPasskeyResponse) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, core.ErrPasskeysNotConfigured) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		if errors.Is(err, core.ErrEmailNotVerified) || errors.Is(err, core.ErrAccountDisabled) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to finish passkey login")
+	}
+
+	return &authv1.FinishPasskeyLoginResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}, nil
+}
+
+func (s *server) StartOTPLogin(ctx context.Context, req *authv1.StartOTPLoginRequest) (*authv1.StartOTPLoginResponse, error) {
+	loginID, err := s.auth.StartOTPLogin(ctx, req.GetUsername(), req.GetChannel())
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		var validationErr *core.ValidationError
+		if errors.As(err, &validationErr) {
+			return nil, invalidArgument(validationErr)
+		}
+		if errors.Is(err, core.ErrSMSNotConfigured) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to start otp login")
+	}
+
+	return &authv1.StartOTPLoginResponse{LoginId: loginID}, nil
+}
+
+func (s *server) CompleteOTPLogin(ctx context.Context, req *authv1.CompleteOTPLoginRequest) (*authv1.CompleteOTPLoginResponse, error) {
+	result, err := s.auth.CompleteOTPLogin(ctx, req.GetLoginId(), req.GetCode())
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		var retryErr *core.RetryError
+		if errors.As(err, &retryErr) {
+			return nil, lockoutError(ctx, retryErr)
+		}
+		if errors.Is(err, core.ErrInvalidOTP) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, core.ErrEmailNotVerified) || errors.Is(err, core.ErrAccountDisabled) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to complete otp login")
+	}
+
+	if result.MFAToken != "" {
+		return &authv1.CompleteOTPLoginResponse{
+			MfaRequired: true,
+			MfaToken:    result.MFAToken,
+		}, nil
+	}
+
+	return &authv1.CompleteOTPLoginResponse{
+		Token:        result.Tokens.AccessToken,
+		RefreshToken: result.Tokens.RefreshToken,
+	}, nil
+}
+
+func (s *server) SendVerificationEmail(ctx context.Context, req *authv1.SendVerificationEmailRequest) (*authv1.SendVerificationEmailResponse, error) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	err = s.auth.SendVerificationEmail(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		if errors.Is(err, core.ErrNoEmail) || errors.Is(err, core.ErrEmailAlreadyVerified) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to send verification email")
+	}
+
+	return &authv1.SendVerificationEmailResponse{}, nil
+}
+
+func (s *server) VerifyEmail(ctx context.Context, req *authv1.VerifyEmailRequest) (*authv1.VerifyEmailResponse, error) {
+	err := s.auth.VerifyEmail(ctx, req.GetToken())
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		if errors.Is(err, core.ErrInvalidVerificationToken) || errors.Is(err, core.ErrUserNotFound) {
+			return nil, status.Error(codes.InvalidArgument, core.ErrInvalidVerificationToken.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to verify email")
+	}
+
+	return &authv1.VerifyEmailResponse{}, nil
+}
+
+func (s *server) SetPhone(ctx context.Context, req *authv1.SetPhoneRequest) (*authv1.SetPhoneResponse, error) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	err = s.auth.SetPhone(ctx, userID, req.GetPassword(), req.GetPhone())
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		var validationErr *core.ValidationError
+		if errors.As(err, &validationErr) {
+			return nil, invalidArgument(validationErr)
+		}
+		if errors.Is(err, core.ErrInvalidCredentials) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, core.ErrPhoneAlreadyExists) {
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		}
+		if errors.Is(err, core.ErrSMSNotConfigured) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to set phone")
+	}
+
+	return &authv1.SetPhoneResponse{}, nil
+}
+
+func (s *server) VerifyPhone(ctx context.Context, req *authv1.VerifyPhoneRequest) (*authv1.VerifyPhoneResponse, error) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	err = s.auth.VerifyPhone(ctx, userID, req.GetCode())
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		if errors.Is(err, core.ErrInvalidOTP) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to verify phone")
+	}
+
+	return &authv1.VerifyPhoneResponse{}, nil
+}
+
+func (s *server) ListSessions(ctx context.Context, req *authv1.ListSessionsRequest) (*authv1.ListSessionsResponse, error) {
+	claims, err := getClaimsFromContext(ctx)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	sessions, err := s.auth.ListSessions(ctx, claims.UserID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, status.Error(codes.Internal, "failed to list sessions")
+	}
+
+	resp := &authv1.ListSessionsResponse{
+		Sessions: make([]*authv1.Session, 0, len(sessions)),
+	}
+
+	for _, session := range sessions {
+		resp.Sessions = append(resp.Sessions, &authv1.Session{
+			Id:         session.ID,
+			DeviceName: session.DeviceName,
+			UserAgent:  session.UserAgent,
+			Ip:         session.IP,
+			CreatedAt:  session.CreatedAt.Unix(),
+			LastSeenAt: session.LastSeenAt.Unix(),
+			Current:    session.ID == claims.SessionID,
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *server) RevokeSession(ctx context.Context, req *authv1.RevokeSessionRequest) (*authv1.RevokeSessionResponse, error) {
+	userID, err := getUserIDFromContext(ctx)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	err = s.auth.RevokeSession(ctx, userID, req.GetSessionId())
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		if errors.Is(err, core.ErrSessionNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to revoke session")
 	}
 
-	return &authv1.UpdatePasswordResponse{}, nil
+	return &authv1.RevokeSessionResponse{}, nil
 }
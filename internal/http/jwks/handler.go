@@ -0,0 +1,36 @@
+package jwks
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/logger"
+)
+
+const path = "/.well-known/jwks.json"
+
+type handler struct {
+	auth core.AuthService
+}
+
+func Register(mux *http.ServeMux, auth core.AuthService) {
+	mux.Handle("GET "+path, &handler{auth: auth})
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	body := struct {
+		Keys []core.JWK `json:"keys"`
+	}{
+		Keys: h.auth.GetJWKS(ctx),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger.Log().Error(ctx, err.Error())
+	}
+}
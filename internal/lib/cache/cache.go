@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepEvery is the number of writes after which expired items are removed
+const sweepEvery = 1024
+
+type item[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// Cache is a small in-process cache with fixed ttl for every item
+type Cache[K comparable, V any] struct {
+	mu     sync.RWMutex
+	items  map[K]item[V]
+	ttl    time.Duration
+	writes int
+}
+
+func New[K comparable, V any](ttl time.Duration) *Cache[K, V] {
+	return &Cache[K, V]{
+		items: make(map[K]item[V]),
+		ttl:   ttl,
+	}
+}
+
+func (c *Cache[K, V]) Get(key K) (value V, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	it, ok := c.items[key]
+	if !ok || time.Now().After(it.expiresAt) {
+		return value, false
+	}
+
+	return it.value, true
+}
+
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.writes++
+	if c.writes%sweepEvery == 0 {
+		c.sweep()
+	}
+
+	c.items[key] = item[V]{
+		value:     value,
+		expiresAt: time.Now().Add(c.ttl),
+	}
+}
+
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.items, key)
+}
+
+func (c *Cache[K, V]) sweep() {
+	now := time.Now()
+	for key, it := range c.items {
+		if now.After(it.expiresAt) {
+			delete(c.items, key)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package canonical
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	MinUsernameLength = 3
+	MaxUsernameLength = 32
+
+	// usernameSymbols are allowed besides letters and digits
+	usernameSymbols = "_.-"
+
+	// prolongedSoundMark "ー" is shared by hiragana and katakana, so it has no script
+	prolongedSoundMark = '\u30fc'
+)
+
+var (
+	ErrUsernameLength      = errors.New("username length is out of range")
+	ErrUsernameCharacters  = errors.New("username may contain only letters, digits and " + usernameSymbols)
+	ErrUsernameMixedScript = errors.New("username mixes letters of different scripts")
+	ErrUsernameScript      = errors.New("username may contain only latin, chinese, japanese and korean letters")
+)
+
+// allowedScripts have no letters that look like latin ones, so whole username
+// in another script like cyrillic "раypal" can not impersonate a latin one
+var allowedScripts = map[string]*unicode.RangeTable{
+	"Latin":    latinLetters,
+	"Han":      unicode.Han,
+	"Hiragana": unicode.Hiragana,
+	"Katakana": unicode.Katakana,
+	"Hangul":   unicode.Hangul,
+	"Bopomofo": unicode.Bopomofo,
+}
+
+// latinLetters are latin letters of written languages, phonetic letters
+// like "ɑ" and "ɡ" look like basic ones and are not allowed
+var latinLetters = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x0041, Hi: 0x005a, Stride: 1},
+		{Lo: 0x0061, Hi: 0x007a, Stride: 1},
+		{Lo: 0x00c0, Hi: 0x017f, Stride: 1},
+		{Lo: 0x01a0, Hi: 0x01a1, Stride: 1},
+		{Lo: 0x01af, Hi: 0x01b0, Stride: 1},
+		{Lo: 0x1e00, Hi: 0x1eff, Stride: 1},
+	},
+}
+
+// Scripts that are written together, so mixing them is not suspicious
+var compatibleScripts = [][]string{
+	{"Han", "Hiragana", "Katakana"},
+	{"Han", "Hangul"},
+	{"Han", "Bopomofo"},
+}
+
+// Username returns canonical form of the username that is compared instead of the
+// username itself. Compatibility characters are decomposed (NFKC) and case is folded,
+// so "DJ_Max", "dj_max" and "ＤＪ＿Ｍａｘ" have the same canonical form
+func Username(username string) string {
+	username = norm.NFKC.String(strings.TrimSpace(username))
+
+	// Case folding may produce not normalized string, so it is normalized again
+	return norm.NFKC.String(cases.Fold().String(username))
+}
+
+// ValidateUsername checks canonical username, letters of one username have to be of the
+// same allowed script, so "pаypal" with cyrillic "а" can not impersonate "paypal".
+// Digits are ascii only and combining marks that NFKC did not compose are rejected,
+// as they can make a letter look like another one
+func ValidateUsername(canonical string) error {
+	length := utf8.RuneCountInString(canonical)
+	if length < MinUsernameLength || length > MaxUsernameLength {
+		return ErrUsernameLength
+	}
+
+	scripts := make(map[string]struct{})
+	for _, r := range canonical {
+		switch {
+		case strings.ContainsRune(usernameSymbols, r), '0' <= r && r <= '9', r == prolongedSoundMark:
+			continue
+		case unicode.IsLetter(r):
+			script := scriptOf(r)
+			if table, ok := allowedScripts[script]; !ok || !unicode.Is(table, r) {
+				return ErrUsernameScript
+			}
+			scripts[script] = struct{}{}
+		case unicode.IsDigit(r):
+			return ErrUsernameScript
+		default:
+			return ErrUsernameCharacters
+		}
+	}
+
+	if len(scripts) > 1 && !compatible(scripts) {
+		return ErrUsernameMixedScript
+	}
+
+	return nil
+}
+
+// scriptOf returns script of the rune, empty for characters shared by scripts
+func scriptOf(r rune) string {
+	if unicode.In(r, unicode.Common, unicode.Inherited) {
+		return ""
+	}
+
+	for name, table := range unicode.Scripts {
+		if unicode.Is(table, r) {
+			return name
+		}
+	}
+
+	return ""
+}
+
+func compatible(scripts map[string]struct{}) bool {
+	for _, group := range compatibleScripts {
+		matched := 0
+		for _, script := range group {
+			if _, ok := scripts[script]; ok {
+				matched++
+			}
+		}
+
+		if matched == len(scripts) {
+			return true
+		}
+	}
+
+	return false
+}
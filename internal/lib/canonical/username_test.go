@@ -0,0 +1,77 @@
+package canonical
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUsername(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		want     string
+	}{
+		{"ascii", "dj_max", "dj_max"},
+		{"upper case", "DJ_Max", "dj_max"},
+		{"fullwidth", "ＤＪ＿Ｍａｘ", "dj_max"},
+		{"surrounding spaces", "  dj_max ", "dj_max"},
+		{"ligature", "ﬁre", "fire"},
+		{"sharp s", "Straße", "strasse"},
+		{"long s", "ſam", "sam"},
+		{"decomposed accent", "Jose\u0301", "jos\u00e9"},
+		{"halfwidth katakana", "ﾏｯｸｽ", "マックス"},
+		{"kelvin sign", "\u212aing", "king"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Username(tt.username); got != tt.want {
+				t.Errorf("Username(%q) = %q, want %q", tt.username, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateUsername(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		want     error
+	}{
+		{"latin", "dj_max", nil},
+		{"digits and symbols", "max-2.0", nil},
+		{"latin with accents", "josé", nil},
+		{"vietnamese", "nguyễn", nil},
+		{"japanese", "まっくす", nil},
+		{"japanese with kanji", "東京のマックス", nil},
+		{"prolonged sound mark", "スーパー", nil},
+		{"korean", "김맥스", nil},
+		{"korean with hanja", "韓國맥스", nil},
+		{"chinese", "麦克斯", nil},
+
+		{"too short", "dj", ErrUsernameLength},
+		{"too long", "max_max_max_max_max_max_max_max_max", ErrUsernameLength},
+		{"space", "dj max", ErrUsernameCharacters},
+		{"at sign", "dj@max", ErrUsernameCharacters},
+		{"emoji", "dj_max🎧", ErrUsernameCharacters},
+
+		{"cyrillic letter in latin", "pаypal", ErrUsernameScript},
+		{"whole cyrillic", "раура", ErrUsernameScript},
+		{"greek", "ραγ", ErrUsernameScript},
+		{"ipa letter", "pɑypal", ErrUsernameScript},
+		{"dental click", "ǀogin", ErrUsernameScript},
+		{"arabic digits", "max٣", ErrUsernameScript},
+		{"combining mark", "ma\u0338x", ErrUsernameCharacters},
+		{"latin and katakana", "djマックス", ErrUsernameMixedScript},
+		{"hangul and hiragana", "맥스まっく", ErrUsernameMixedScript},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateUsername(Username(tt.username))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("ValidateUsername(%q) = %v, want %v", tt.username, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+)
+
+var (
+	ErrTokenExpired     = errors.New("token is expired")
+	ErrTokenNotValidYet = errors.New("token is not valid yet")
+	ErrInvalidIssuer    = errors.New("invalid token issuer")
+	ErrInvalidAudience  = errors.New("invalid token audience")
+	ErrInvalidClaims    = errors.New("invalid token claims")
+	ErrInvalidTokenUse  = errors.New("invalid token use")
+)
+
+// Claims are registered (RFC 7519) and private claims of access token
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	IssuedAt  int64    `json:"iat"`
+	NotBefore int64    `json:"nbf"`
+	ExpiresAt int64    `json:"exp"`
+	ID        string   `json:"jti"`
+
+	// Use separates access tokens from other tokens signed with the same keys
+	Use         string   `json:"use"`
+	SessionID   string   `json:"sid,omitempty"`
+	Generation  int      `json:"gen"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	// Scope is space separated list as in RFC 8693
+	Scope string `json:"scope,omitempty"`
+
+	// Email claims as in OpenID Connect Core
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+}
+
+// Valid is called by the parser without leeway, so claims are checked by Validate instead
+func (c *Claims) Valid() error {
+	return nil
+}
+
+// ValidationOptions are checks applied to registered claims
+type ValidationOptions struct {
+	Issuer string
+	// Token is accepted if it has at least one of audiences, empty list disables the check
+	Audiences []string
+	// Leeway is allowed clock skew between issuer and verifier
+	Leeway time.Duration
+	// Use is the only accepted token use, access tokens are accepted if empty
+	Use string
+}
+
+func (c *Claims) Validate(now time.Time, opts ValidationOptions) error {
+	if c.ExpiresAt == 0 || now.After(time.Unix(c.ExpiresAt, 0).Add(opts.Leeway)) {
+		return ErrTokenExpired
+	}
+
+	if c.NotBefore != 0 && now.Add(opts.Leeway).Before(time.Unix(c.NotBefore, 0)) {
+		return ErrTokenNotValidYet
+	}
+
+	if c.IssuedAt != 0 && now.Add(opts.Leeway).Before(time.Unix(c.IssuedAt, 0)) {
+		return ErrTokenNotValidYet
+	}
+
+	if opts.Issuer != "" && c.Issuer != opts.Issuer {
+		return ErrInvalidIssuer
+	}
+
+	if len(opts.Audiences) > 0 && !slices.ContainsFunc(c.Audience, func(aud string) bool {
+		return slices.Contains(opts.Audiences, aud)
+	}) {
+		return ErrInvalidAudience
+	}
+
+	use := opts.Use
+	if use == "" {
+		use = core.TokenUseAccess
+	}
+
+	if c.Use != use {
+		return ErrInvalidTokenUse
+	}
+
+	if c.ID == "" {
+		return ErrInvalidClaims
+	}
+
+	return nil
+}
+
+func newClaims(claims core.Claims) *Claims {
+	var emailVerified *bool
+	if claims.Email != "" {
+		emailVerified = &claims.EmailVerified
+	}
+
+	return &Claims{
+		Subject:     strconv.Itoa(claims.UserID),
+		Issuer:      claims.Issuer,
+		Audience:    claims.Audience,
+		IssuedAt:    claims.IssuedAt.Unix(),
+		NotBefore:   claims.IssuedAt.Unix(),
+		ExpiresAt:   claims.ExpiresAt.Unix(),
+		ID:          claims.ID,
+		Use:         claims.Use,
+		SessionID:   claims.SessionID,
+		Generation:  claims.Generation,
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+		Scope:       strings.Join(claims.Scopes, " "),
+
+		Email:         claims.Email,
+		EmailVerified: emailVerified,
+	}
+}
+
+func (c *Claims) core() (*core.Claims, error) {
+	userID, err := strconv.Atoi(c.Subject)
+	if err != nil {
+		return nil, ErrInvalidClaims
+	}
+
+	return &core.Claims{
+		ID:            c.ID,
+		Use:           c.Use,
+		UserID:        userID,
+		SessionID:     c.SessionID,
+		Generation:    c.Generation,
+		Issuer:        c.Issuer,
+		Audience:      c.Audience,
+		Roles:         c.Roles,
+		Permissions:   c.Permissions,
+		Scopes:        strings.Fields(c.Scope),
+		Email:         c.Email,
+		EmailVerified: c.EmailVerified != nil && *c.EmailVerified,
+		IssuedAt:      time.Unix(c.IssuedAt, 0),
+		ExpiresAt:     time.Unix(c.ExpiresAt, 0),
+	}, nil
+}
+
+// Audience is a list of audiences, in json it is either a string or an array of strings
+type Audience []string
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+
+	return json.Marshal([]string(a))
+}
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+
+	*a = list
+
+	return nil
+}
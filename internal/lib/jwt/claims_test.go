@@ -0,0 +1,144 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+)
+
+var (
+	testNow     = time.Unix(1700000000, 0)
+	testOptions = ValidationOptions{
+		Issuer:    "https://auth.beatflow.example",
+		Audiences: []string{"beatflow"},
+		Leeway:    30 * time.Second,
+	}
+)
+
+func validClaims() Claims {
+	return Claims{
+		Subject:   "1",
+		Issuer:    "https://auth.beatflow.example",
+		Audience:  Audience{"beatflow"},
+		IssuedAt:  testNow.Add(-time.Minute).Unix(),
+		NotBefore: testNow.Add(-time.Minute).Unix(),
+		ExpiresAt: testNow.Add(time.Minute).Unix(),
+		ID:        "token-id",
+		Use:       core.TokenUseAccess,
+	}
+}
+
+func TestClaimsValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Claims)
+		opts   func(o *ValidationOptions)
+		want   error
+	}{
+		{"valid", func(c *Claims) {}, nil, nil},
+		{"wrong issuer", func(c *Claims) { c.Issuer = "https://evil.example" }, nil, ErrInvalidIssuer},
+		{"wrong audience", func(c *Claims) { c.Audience = Audience{"other"} }, nil, ErrInvalidAudience},
+		{"one of audiences", func(c *Claims) { c.Audience = Audience{"other", "beatflow"} }, nil, nil},
+		{"no audience check", func(c *Claims) { c.Audience = nil }, func(o *ValidationOptions) { o.Audiences = nil }, nil},
+		{"expired inside leeway", func(c *Claims) { c.ExpiresAt = testNow.Add(-10 * time.Second).Unix() }, nil, nil},
+		{"expired outside leeway", func(c *Claims) { c.ExpiresAt = testNow.Add(-time.Minute).Unix() }, nil, ErrTokenExpired},
+		{"no expiry", func(c *Claims) { c.ExpiresAt = 0 }, nil, ErrTokenExpired},
+		{"not before inside leeway", func(c *Claims) { c.NotBefore = testNow.Add(10 * time.Second).Unix() }, nil, nil},
+		{"not before outside leeway", func(c *Claims) { c.NotBefore = testNow.Add(time.Minute).Unix() }, nil, ErrTokenNotValidYet},
+		{"issued in future", func(c *Claims) { c.IssuedAt = testNow.Add(time.Minute).Unix() }, nil, ErrTokenNotValidYet},
+		{"mfa token as access token", func(c *Claims) { c.Use = core.TokenUseMFA }, nil, ErrInvalidTokenUse},
+		{"email token as access token", func(c *Claims) { c.Use = core.TokenUseEmailVerification }, nil, ErrInvalidTokenUse},
+		{"token without use", func(c *Claims) { c.Use = "" }, nil, ErrInvalidTokenUse},
+		{"mfa token", func(c *Claims) { c.Use = core.TokenUseMFA }, func(o *ValidationOptions) { o.Use = core.TokenUseMFA }, nil},
+		{"access token as mfa token", func(c *Claims) {}, func(o *ValidationOptions) { o.Use = core.TokenUseMFA }, ErrInvalidTokenUse},
+		{"no id", func(c *Claims) { c.ID = "" }, nil, ErrInvalidClaims},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims()
+			tt.modify(&claims)
+
+			opts := testOptions
+			if tt.opts != nil {
+				tt.opts(&opts)
+			}
+
+			if err := claims.Validate(testNow, opts); !errors.Is(err, tt.want) {
+				t.Errorf("Validate = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifierRejectsMFAToken(t *testing.T) {
+	keyring, err := NewKeyring(StaticKey(func() (*Key, error) {
+		return NewHMACKey("test", "0123456789abcdef0123456789abcdef")
+	}))
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+
+	now := time.Now()
+	token, err := GenerateToken(core.Claims{
+		ID:        "mfa-token",
+		Use:       core.TokenUseMFA,
+		UserID:    1,
+		Issuer:    testOptions.Issuer,
+		Audience:  []string{"beatflow"},
+		IssuedAt:  now,
+		ExpiresAt: now.Add(time.Minute),
+	}, keyring)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	verifier := NewVerifier(keyring, testOptions)
+
+	if _, err := verifier.Verify(*token); err == nil {
+		t.Error("access token verifier accepted mfa token")
+	}
+
+	claims, err := verifier.WithUse(core.TokenUseMFA).Verify(*token)
+	if err != nil {
+		t.Fatalf("mfa verifier: %v", err)
+	}
+
+	if claims.UserID != 1 || claims.Use != core.TokenUseMFA {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+}
+
+func TestAudienceJSON(t *testing.T) {
+	tests := []struct {
+		data string
+		want Audience
+	}{
+		{`"beatflow"`, Audience{"beatflow"}},
+		{`["beatflow","studio"]`, Audience{"beatflow", "studio"}},
+	}
+
+	for _, tt := range tests {
+		var aud Audience
+		if err := json.Unmarshal([]byte(tt.data), &aud); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", tt.data, err)
+		}
+
+		if !slices.Equal(aud, tt.want) {
+			t.Errorf("Unmarshal(%s) = %v, want %v", tt.data, aud, tt.want)
+		}
+
+		data, err := json.Marshal(aud)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+
+		if string(data) != tt.data {
+			t.Errorf("Marshal(%v) = %s, want %s", aud, data, tt.data)
+		}
+	}
+}
@@ -1,22 +1,97 @@
 package jwt
 
 import (
+	"errors"
 	"time"
 
 	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
 	jwtlib "github.com/golang-jwt/jwt"
 )
 
-func GenerateToken(id int, authConfig core.AuthConfig) (*string, error) {
-	token := jwtlib.NewWithClaims(jwtlib.SigningMethodHS256, jwtlib.MapClaims{
-		"id":  id,
-		"exp": time.Now().Add(time.Minute * time.Duration(authConfig.TokenTTL)).Unix(),
-	})
+func GenerateToken(claims core.Claims, keyring *Keyring) (*string, error) {
+	key := keyring.SigningKey()
+
+	token := jwtlib.NewWithClaims(key.Method, newClaims(claims))
+	token.Header["kid"] = key.ID
 
-	tokenString, err := token.SignedString([]byte(authConfig.Secret))
+	tokenString, err := token.SignedString(key.PrivateKey)
 	if err != nil {
 		return nil, err
 	}
 
 	return &tokenString, nil
 }
+
+// KeySet finds verification key by kid
+type KeySet interface {
+	VerificationKey(kid string) (*Key, error)
+}
+
+// Verifier checks token signature against key set and validates registered claims
+type Verifier struct {
+	keys   KeySet
+	opts   ValidationOptions
+	parser *jwtlib.Parser
+}
+
+func NewVerifier(keys KeySet, opts ValidationOptions) *Verifier {
+	return &Verifier{
+		keys:   keys,
+		opts:   opts,
+		parser: &jwtlib.Parser{SkipClaimsValidation: true},
+	}
+}
+
+// WithAudience returns verifier that accepts only the audience
+func (v *Verifier) WithAudience(audience string) *Verifier {
+	opts := v.opts
+	opts.Audiences = []string{audience}
+
+	return NewVerifier(v.keys, opts)
+}
+
+// WithUse returns verifier that accepts only tokens of the use
+func (v *Verifier) WithUse(use string) *Verifier {
+	opts := v.opts
+	opts.Use = use
+
+	return NewVerifier(v.keys, opts)
+}
+
+func (v *Verifier) Verify(tokenString string) (*core.Claims, error) {
+	claims := new(Claims)
+
+	token, err := v.parser.ParseWithClaims(tokenString, claims, func(t *jwtlib.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+
+		key, err := v.keys.VerificationKey(kid)
+		if err != nil {
+			return nil, err
+		}
+
+		// Algorithm is pinned to the key, so public key can never be used as hmac secret
+		if t.Method.Alg() != key.Method.Alg() {
+			return nil, core.ErrUnauthorized
+		}
+
+		return key.PublicKey, nil
+	})
+	if err != nil {
+		// Parser hides key lookup errors, they are needed by callers
+		var validationErr *jwtlib.ValidationError
+		if errors.As(err, &validationErr) && validationErr.Inner != nil {
+			return nil, validationErr.Inner
+		}
+		return nil, err
+	}
+
+	if !token.Valid {
+		return nil, core.ErrUnauthorized
+	}
+
+	if err := claims.Validate(time.Now(), v.opts); err != nil {
+		return nil, err
+	}
+
+	return claims.core()
+}
@@ -0,0 +1,291 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	jwtlib "github.com/golang-jwt/jwt"
+)
+
+var (
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	ErrKeyMismatch          = errors.New("key does not match signing algorithm")
+)
+
+const defaultHMACKeyID = "default"
+
+// Key is a signing key, for asymmetric algorithms only public part is used for verification
+type Key struct {
+	ID         string
+	Method     jwtlib.SigningMethod
+	PrivateKey crypto.PrivateKey
+	PublicKey  crypto.PublicKey
+
+	// Active key signs new tokens, the rest of keys are only used for verification
+	Active bool
+	// RetireAt is the time after which tokens signed with the key are rejected, zero means never
+	RetireAt time.Time
+}
+
+// NewHMACKey creates symmetric HS256 key from shared secret
+func NewHMACKey(id string, secret string) (*Key, error) {
+	if secret == "" {
+		return nil, core.ErrInvalidAuthConfig
+	}
+
+	if id == "" {
+		id = defaultHMACKeyID
+	}
+
+	return &Key{
+		ID:         id,
+		Method:     jwtlib.SigningMethodHS256,
+		PrivateKey: []byte(secret),
+		PublicKey:  []byte(secret),
+	}, nil
+}
+
+// LoadKey reads PEM encoded private key, if id is empty
+// RFC 7638 thumbprint of public key is used as key id
+func LoadKey(id string, algorithm string, privateKeyPath string) (*Key, error) {
+	data, err := os.ReadFile(privateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{ID: id}
+
+	switch algorithm {
+	case "RS256", "RS384", "RS512":
+		privateKey, err := jwtlib.ParseRSAPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		key.PrivateKey, key.PublicKey = privateKey, &privateKey.PublicKey
+	case "ES256", "ES384", "ES512":
+		privateKey, err := jwtlib.ParseECPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		if curveAlgorithm(privateKey.Curve) != algorithm {
+			return nil, ErrKeyMismatch
+		}
+		key.PrivateKey, key.PublicKey = privateKey, &privateKey.PublicKey
+	case "EdDSA":
+		privateKey, err := jwtlib.ParseEdPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		key.PrivateKey, key.PublicKey = privateKey, privateKey.(ed25519.PrivateKey).Public()
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+	}
+
+	key.Method = jwtlib.GetSigningMethod(algorithm)
+
+	if key.ID == "" {
+		key.ID, err = thumbprint(key)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return key, nil
+}
+
+// LoadPublicKey reads PEM encoded public key, such keys can only verify tokens
+func LoadPublicKey(id string, algorithm string, publicKeyPath string) (*Key, error) {
+	data, err := os.ReadFile(publicKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{ID: id}
+
+	switch algorithm {
+	case "RS256", "RS384", "RS512":
+		key.PublicKey, err = jwtlib.ParseRSAPublicKeyFromPEM(data)
+	case "ES256", "ES384", "ES512":
+		var publicKey *ecdsa.PublicKey
+		publicKey, err = jwtlib.ParseECPublicKeyFromPEM(data)
+		if err == nil && curveAlgorithm(publicKey.Curve) != algorithm {
+			err = ErrKeyMismatch
+		}
+		key.PublicKey = publicKey
+	case "EdDSA":
+		key.PublicKey, err = jwtlib.ParseEdPublicKeyFromPEM(data)
+	default:
+		err = fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key.Method = jwtlib.GetSigningMethod(algorithm)
+
+	if key.ID == "" {
+		key.ID, err = thumbprint(key)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return key, nil
+}
+
+// Retired reports whether tokens signed with the key are no longer accepted
+func (k *Key) Retired(now time.Time) bool {
+	return !k.RetireAt.IsZero() && now.After(k.RetireAt)
+}
+
+// JWK returns public part of the key, symmetric keys are never published
+func (k *Key) JWK() (core.JWK, bool) {
+	jwk := core.JWK{
+		Kid: k.ID,
+		Use: "sig",
+		Alg: k.Method.Alg(),
+	}
+
+	switch publicKey := k.PublicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = encode(publicKey.N.Bytes())
+		jwk.E = encode(big.NewInt(int64(publicKey.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = publicKey.Curve.Params().Name
+		jwk.X = encode(publicKey.X.FillBytes(make([]byte, size)))
+		jwk.Y = encode(publicKey.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = encode(publicKey)
+	default:
+		return core.JWK{}, false
+	}
+
+	return jwk, true
+}
+
+// KeyFromJWK converts published public key back to verification key
+func KeyFromJWK(jwk core.JWK) (*Key, error) {
+	key := &Key{
+		ID:     jwk.Kid,
+		Method: jwtlib.GetSigningMethod(jwk.Alg),
+	}
+
+	if key.Method == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, jwk.Alg)
+	}
+
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decode(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		key.PublicKey = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, ErrKeyMismatch
+		}
+		if curveAlgorithm(curve) != jwk.Alg {
+			return nil, ErrKeyMismatch
+		}
+		x, err := decode(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		key.PublicKey = &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+	case "OKP":
+		x, err := decode(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		if jwk.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, ErrKeyMismatch
+		}
+		key.PublicKey = ed25519.PublicKey(x)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, jwk.Kty)
+	}
+
+	return key, nil
+}
+
+// thumbprint computes RFC 7638 key thumbprint, members are in lexicographic order
+func thumbprint(k *Key) (string, error) {
+	jwk, ok := k.JWK()
+	if !ok {
+		return "", ErrUnsupportedAlgorithm
+	}
+
+	var members string
+	switch jwk.Kty {
+	case "RSA":
+		members = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, jwk.E, jwk.N)
+	case "EC":
+		members = fmt.Sprintf(`{"crv":"%s","kty":"EC","x":"%s","y":"%s"}`, jwk.Crv, jwk.X, jwk.Y)
+	case "OKP":
+		members = fmt.Sprintf(`{"crv":"%s","kty":"OKP","x":"%s"}`, jwk.Crv, jwk.X)
+	}
+
+	sum := sha256.Sum256([]byte(members))
+	return encode(sum[:]), nil
+}
+
+func curveAlgorithm(curve elliptic.Curve) string {
+	switch curve {
+	case elliptic.P256():
+		return "ES256"
+	case elliptic.P384():
+		return "ES384"
+	case elliptic.P521():
+		return "ES512"
+	}
+
+	return ""
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
@@ -0,0 +1,207 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+)
+
+var (
+	ErrUnknownKey     = errors.New("unknown signing key")
+	ErrRetiredKey     = errors.New("signing key is retired")
+	ErrNoActiveKey    = errors.New("keyring must contain exactly one active key")
+	ErrDuplicateKeyID = errors.New("duplicate key id")
+)
+
+// KeyLoader returns all keys of the keyring, exactly one of them must be active
+type KeyLoader func() ([]*Key, error)
+
+// Keyring holds the key that signs new tokens and keys that are still
+// accepted for verification, keys are selected by kid header
+type Keyring struct {
+	mu      sync.RWMutex
+	signing *Key
+	keys    map[string]*Key
+	load    KeyLoader
+}
+
+func NewKeyring(load KeyLoader) (*Keyring, error) {
+	k := &Keyring{load: load}
+
+	if err := k.Reload(); err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// Reload loads keys again and swaps them atomically,
+// on error the keyring keeps previous keys
+func (k *Keyring) Reload() error {
+	keys, err := k.load()
+	if err != nil {
+		return err
+	}
+
+	var signing *Key
+	byID := make(map[string]*Key, len(keys))
+	for _, key := range keys {
+		if _, ok := byID[key.ID]; ok {
+			return fmt.Errorf("%w: %s", ErrDuplicateKeyID, key.ID)
+		}
+		byID[key.ID] = key
+
+		if key.Active {
+			if signing != nil {
+				return ErrNoActiveKey
+			}
+			signing = key
+		}
+	}
+
+	if signing == nil {
+		return ErrNoActiveKey
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.signing = signing
+	k.keys = byID
+
+	return nil
+}
+
+func (k *Keyring) SigningKey() *Key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.signing
+}
+
+// VerificationKey returns key by kid, tokens without kid were issued
+// before keys had ids, so they are checked against the signing key
+func (k *Keyring) VerificationKey(kid string) (*Key, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if kid == "" {
+		return k.signing, nil
+	}
+
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	if key.Retired(time.Now()) {
+		return nil, ErrRetiredKey
+	}
+
+	return key, nil
+}
+
+// JWKS returns public parts of all keys that are not retired yet
+func (k *Keyring) JWKS() []core.JWK {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	now := time.Now()
+
+	jwks := make([]core.JWK, 0, len(k.keys))
+	for _, key := range k.keys {
+		if key.Retired(now) {
+			continue
+		}
+
+		if jwk, ok := key.JWK(); ok {
+			jwks = append(jwks, jwk)
+		}
+	}
+
+	return jwks
+}
+
+// StaticKey returns loader for a single key that is always active
+func StaticKey(load func() (*Key, error)) KeyLoader {
+	return func() ([]*Key, error) {
+		key, err := load()
+		if err != nil {
+			return nil, err
+		}
+
+		key.Active = true
+
+		return []*Key{key}, nil
+	}
+}
+
+type keyringFile struct {
+	Keys []struct {
+		ID         string    `json:"id"`
+		Algorithm  string    `json:"algorithm"`
+		PrivateKey string    `json:"private_key"`
+		PublicKey  string    `json:"public_key"`
+		Active     bool      `json:"active"`
+		RetireAt   time.Time `json:"retire_at"`
+	} `json:"keys"`
+}
+
+// KeyringFile returns loader for keyring described by json file:
+//
+//	{"keys": [
+//	  {"id": "2024-10", "algorithm": "ES256", "private_key": "keys/2024-10.pem", "active": true},
+//	  {"id": "2024-04", "algorithm": "ES256", "public_key": "keys/2024-04.pub.pem", "retire_at": "2024-11-01T00:00:00Z"}
+//	]}
+//
+// Only the active key needs a private key, for HS256 private_key is a file with the secret
+func KeyringFile(path string) KeyLoader {
+	return func() ([]*Key, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var file keyringFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, err
+		}
+
+		keys := make([]*Key, 0, len(file.Keys))
+		for _, entry := range file.Keys {
+			var key *Key
+
+			switch {
+			case entry.Algorithm == "HS256":
+				var secret []byte
+				secret, err = os.ReadFile(entry.PrivateKey)
+				if err == nil {
+					key, err = NewHMACKey(entry.ID, strings.TrimSpace(string(secret)))
+				}
+			case entry.PrivateKey != "":
+				key, err = LoadKey(entry.ID, entry.Algorithm, entry.PrivateKey)
+			default:
+				if entry.Active {
+					return nil, fmt.Errorf("active key %s has no private key", entry.ID)
+				}
+				key, err = LoadPublicKey(entry.ID, entry.Algorithm, entry.PublicKey)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("key %s: %w", entry.ID, err)
+			}
+
+			key.Active = entry.Active
+			key.RetireAt = entry.RetireAt
+
+			keys = append(keys, key)
+		}
+
+		return keys, nil
+	}
+}
@@ -0,0 +1,47 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+)
+
+type fileNotifier struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFile appends notifications to the file as json lines
+func NewFile(path string) core.Notifier {
+	return &fileNotifier{path: path}
+}
+
+func (n *fileNotifier) Notify(ctx context.Context, notification core.Notification) error {
+	line, err := json.Marshal(struct {
+		Time time.Time `json:"time"`
+		core.Notification
+	}{
+		Time:         time.Now(),
+		Notification: notification,
+	})
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+
+	return err
+}
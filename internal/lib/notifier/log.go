@@ -0,0 +1,22 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/logger"
+)
+
+type logNotifier struct{}
+
+// NewLog writes notifications to the log, it is meant for local development
+func NewLog() core.Notifier {
+	return &logNotifier{}
+}
+
+func (n *logNotifier) Notify(ctx context.Context, notification core.Notification) error {
+	logger.Log().Info(ctx, "notification for user %d (%s): %s\n%s",
+		notification.UserID, notification.Username, notification.Subject, notification.Body)
+
+	return nil
+}
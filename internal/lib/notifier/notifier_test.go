@@ -0,0 +1,177 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+)
+
+func TestFileNotifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.jsonl")
+	n := NewFile(path)
+
+	for _, code := range []string{"111111", "222222"} {
+		err := n.Notify(context.Background(), core.Notification{
+			UserID:   1,
+			Username: "dj_max",
+			To:       "max@example.com",
+			Subject:  "Login code",
+			Body:     "Your login code is " + code,
+		})
+		if err != nil {
+			t.Fatalf("Notify: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+
+	var notification core.Notification
+	if err := json.Unmarshal([]byte(lines[1]), &notification); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	if notification.To != "max@example.com" || notification.Body != "Your login code is 222222" {
+		t.Fatalf("unexpected notification %+v", notification)
+	}
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	server := newFakeSMTP(t)
+
+	n := NewSMTP(SMTPConfig{Host: "127.0.0.1", Port: server.port, From: "auth@example.com"})
+
+	err := n.Notify(context.Background(), core.Notification{
+		UserID:   1,
+		Username: "dj_max",
+		To:       "max@example.com",
+		Subject:  "Login code",
+		Body:     "Your login code is 123456",
+	})
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	mail := <-server.mails
+	if mail.from != "auth@example.com" || mail.to != "max@example.com" {
+		t.Fatalf("unexpected envelope from %q to %q", mail.from, mail.to)
+	}
+
+	for _, want := range []string{"To: max@example.com\r\n", "Subject: Login code\r\n", "Your login code is 123456"} {
+		if !strings.Contains(mail.data, want) {
+			t.Errorf("message does not contain %q:\n%s", want, mail.data)
+		}
+	}
+}
+
+func TestSMTPNotifierNoRecipient(t *testing.T) {
+	n := NewSMTP(SMTPConfig{Host: "127.0.0.1", Port: "25", From: "auth@example.com"})
+
+	err := n.Notify(context.Background(), core.Notification{UserID: 1, Subject: "Login code"})
+	if !errors.Is(err, core.ErrNoRecipient) {
+		t.Fatalf("got %v, want %v", err, core.ErrNoRecipient)
+	}
+}
+
+type fakeMail struct {
+	from string
+	to   string
+	data string
+}
+
+// fakeSMTP accepts one mail at a time without extensions, so no TLS or auth is negotiated
+type fakeSMTP struct {
+	port  string
+	mails chan fakeMail
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	server := &fakeSMTP{port: port, mails: make(chan fakeMail, 1)}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.serve(conn)
+		}
+	}()
+
+	return server
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	var mail fakeMail
+	reply("220 localhost fake")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		switch command := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); command {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "MAIL":
+			mail.from = strings.Trim(strings.TrimPrefix(line[len("MAIL "):], "FROM:"), "<>")
+			reply("250 OK")
+		case "RCPT":
+			mail.to = strings.Trim(strings.TrimPrefix(line[len("RCPT "):], "TO:"), "<>")
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+
+			mail.data = data.String()
+			s.mails <- mail
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+type smtpNotifier struct {
+	config SMTPConfig
+	auth   smtp.Auth
+}
+
+// NewSMTP sends notifications as plain text emails, STARTTLS is used
+// if the server supports it, credentials are sent only over TLS or to localhost
+func NewSMTP(config SMTPConfig) core.Notifier {
+	var auth smtp.Auth
+	if config.Username != "" {
+		auth = smtp.PlainAuth("", config.Username, config.Password, config.Host)
+	}
+
+	return &smtpNotifier{
+		config: config,
+		auth:   auth,
+	}
+}
+
+func (n *smtpNotifier) Notify(ctx context.Context, notification core.Notification) error {
+	if notification.To == "" {
+		return core.ErrNoRecipient
+	}
+
+	// Header injection is not possible through addresses
+	if strings.ContainsAny(notification.To, "\r\n") {
+		return fmt.Errorf("invalid recipient address %q", notification.To)
+	}
+
+	var message strings.Builder
+	fmt.Fprintf(&message, "From: %s\r\n", n.config.From)
+	fmt.Fprintf(&message, "To: %s\r\n", notification.To)
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", notification.Subject))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	message.WriteString("\r\n")
+	message.WriteString(strings.ReplaceAll(notification.Body, "\n", "\r\n"))
+	message.WriteString("\r\n")
+
+	// net/smtp does not accept context, so sending is done in background
+	// and the caller stops waiting once context is done
+	result := make(chan error, 1)
+	go func() {
+		result <- smtp.SendMail(net.JoinHostPort(n.config.Host, n.config.Port), n.auth,
+			n.config.From, []string{notification.To}, []byte(message.String()))
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,267 @@
+package passkey
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+var ErrClonedAuthenticator = errors.New("authenticator may be cloned")
+
+type Config struct {
+	RPID          string
+	RPDisplayName string
+	RPOrigins     []string
+	Timeout       time.Duration
+}
+
+type relyingParty struct {
+	webAuthn *webauthn.WebAuthn
+}
+
+func New(config Config) (core.PasskeyRelyingParty, error) {
+	timeout := webauthn.TimeoutConfig{
+		Enforce:    true,
+		Timeout:    config.Timeout,
+		TimeoutUVD: config.Timeout,
+	}
+
+	webAuthn, err := webauthn.New(&webauthn.Config{
+		RPID:          config.RPID,
+		RPDisplayName: config.RPDisplayName,
+		RPOrigins:     config.RPOrigins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementPreferred,
+			UserVerification: protocol.VerificationPreferred,
+		},
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        timeout,
+			Registration: timeout,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &relyingParty{webAuthn: webAuthn}, nil
+}
+
+func (rp *relyingParty) BeginRegistration(u core.PasskeyUser) ([]byte, []byte, error) {
+	wu := &user{u}
+
+	// Authenticators that already have a credential of the user are excluded
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(u.Credentials))
+	for _, credential := range wu.WebAuthnCredentials() {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	creation, session, err := rp.webAuthn.BeginRegistration(wu, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return marshal(creation, session)
+}
+
+func (rp *relyingParty) FinishRegistration(u core.PasskeyUser, session []byte, response []byte) (*core.PasskeyCredential, error) {
+	var sessionData webauthn.SessionData
+	if err := json.Unmarshal(session, &sessionData); err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(response))
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := rp.webAuthn.CreateCredential(&user{u}, sessionData, parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	passkeyCredential := fromCredential(credential)
+	passkeyCredential.UserID = u.ID
+
+	return passkeyCredential, nil
+}
+
+func (rp *relyingParty) BeginLogin(u *core.PasskeyUser, userVerification bool) ([]byte, []byte, error) {
+	verification := protocol.VerificationPreferred
+	if userVerification {
+		verification = protocol.VerificationRequired
+	}
+
+	var (
+		assertion *protocol.CredentialAssertion
+		session   *webauthn.SessionData
+		err       error
+	)
+
+	if u == nil {
+		assertion, session, err = rp.webAuthn.BeginDiscoverableLogin(webauthn.WithUserVerification(verification))
+	} else {
+		assertion, session, err = rp.webAuthn.BeginLogin(&user{*u}, webauthn.WithUserVerification(verification))
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return marshal(assertion, session)
+}
+
+func (rp *relyingParty) FinishLogin(session []byte, response []byte, lookup func(userID int) (*core.PasskeyUser, error)) (*core.PasskeyCredential, error) {
+	var sessionData webauthn.SessionData
+	if err := json.Unmarshal(session, &sessionData); err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(response))
+	if err != nil {
+		return nil, err
+	}
+
+	// Owner is found by user handle, either from session or from discoverable credential
+	var owner *core.PasskeyUser
+	handler := func(rawID []byte, userHandle []byte) (webauthn.User, error) {
+		userID, err := userIDFromHandle(userHandle)
+		if err != nil {
+			return nil, err
+		}
+
+		owner, err = lookup(userID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &user{*owner}, nil
+	}
+
+	var credential *webauthn.Credential
+	if len(sessionData.UserID) == 0 {
+		credential, err = rp.webAuthn.ValidateDiscoverableLogin(handler, sessionData, parsed)
+	} else {
+		var wu webauthn.User
+		wu, err = handler(parsed.RawID, sessionData.UserID)
+		if err != nil {
+			return nil, err
+		}
+		credential, err = rp.webAuthn.ValidateLogin(wu, sessionData, parsed)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if credential.Authenticator.CloneWarning {
+		return nil, ErrClonedAuthenticator
+	}
+
+	passkeyCredential := fromCredential(credential)
+	passkeyCredential.UserID = owner.ID
+
+	// Stored fields that are not part of webauthn credential are kept
+	for _, stored := range owner.Credentials {
+		if bytes.Equal(stored.CredentialID, passkeyCredential.CredentialID) {
+			passkeyCredential.ID = stored.ID
+			passkeyCredential.Name = stored.Name
+			passkeyCredential.CreatedAt = stored.CreatedAt
+		}
+	}
+
+	return passkeyCredential, nil
+}
+
+func marshal(options any, session *webauthn.SessionData) ([]byte, []byte, error) {
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sessionJSON, err := json.Marshal(session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return optionsJSON, sessionJSON, nil
+}
+
+// user adapts core user to webauthn, user handle is the user id,
+// so it does not reveal anything about the user
+type user struct {
+	core.PasskeyUser
+}
+
+func (u *user) WebAuthnID() []byte {
+	return []byte(strconv.Itoa(u.ID))
+}
+
+func (u *user) WebAuthnName() string {
+	return u.Name
+}
+
+func (u *user) WebAuthnDisplayName() string {
+	return u.Name
+}
+
+func (u *user) WebAuthnIcon() string {
+	return ""
+}
+
+func (u *user) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.Credentials))
+	for _, credential := range u.Credentials {
+		transports := make([]protocol.AuthenticatorTransport, 0, len(credential.Transports))
+		for _, transport := range credential.Transports {
+			transports = append(transports, protocol.AuthenticatorTransport(transport))
+		}
+
+		credentials = append(credentials, webauthn.Credential{
+			ID:              credential.CredentialID,
+			PublicKey:       credential.PublicKey,
+			AttestationType: credential.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: credential.BackupEligible,
+				BackupState:    credential.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    credential.AAGUID,
+				SignCount: credential.SignCount,
+			},
+		})
+	}
+
+	return credentials
+}
+
+func userIDFromHandle(userHandle []byte) (int, error) {
+	userID, err := strconv.Atoi(string(userHandle))
+	if err != nil || userID <= 0 {
+		return 0, errors.New("invalid user handle")
+	}
+
+	return userID, nil
+}
+
+func fromCredential(credential *webauthn.Credential) *core.PasskeyCredential {
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	return &core.PasskeyCredential{
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		Transports:      transports,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+	}
+}
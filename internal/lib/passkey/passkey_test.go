@@ -0,0 +1,273 @@
+package passkey
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+)
+
+const (
+	testRPID   = "beatflow.test"
+	testOrigin = "https://beatflow.test"
+)
+
+// Authenticator data flags
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttested     = 0x40
+)
+
+func TestRegistrationAndLogin(t *testing.T) {
+	rp := newTestRelyingParty(t)
+	authenticator := newSoftwareAuthenticator(t)
+	owner := core.PasskeyUser{ID: 42, Name: "dj_max"}
+
+	credential := register(t, rp, authenticator, owner, testOrigin)
+	if credential.UserID != owner.ID {
+		t.Fatalf("credential belongs to user %d, want %d", credential.UserID, owner.ID)
+	}
+	owner.Credentials = []core.PasskeyCredential{*credential}
+
+	session, response := authenticator.login(t, rp, &owner, testOrigin)
+
+	loggedIn, err := rp.FinishLogin(session, response, func(userID int) (*core.PasskeyUser, error) {
+		if userID != owner.ID {
+			return nil, core.ErrUserNotFound
+		}
+		return &owner, nil
+	})
+	if err != nil {
+		t.Fatalf("FinishLogin: %v", err)
+	}
+
+	if loggedIn.UserID != owner.ID || loggedIn.SignCount != authenticator.signCount {
+		t.Fatalf("unexpected credential %+v", loggedIn)
+	}
+}
+
+func TestRegistrationRejectsOtherOrigin(t *testing.T) {
+	rp := newTestRelyingParty(t)
+	authenticator := newSoftwareAuthenticator(t)
+	owner := core.PasskeyUser{ID: 42, Name: "dj_max"}
+
+	options, session, err := rp.BeginRegistration(owner)
+	if err != nil {
+		t.Fatalf("BeginRegistration: %v", err)
+	}
+
+	response := authenticator.create(t, challengeOf(t, options), "https://evil.test")
+
+	if _, err := rp.FinishRegistration(owner, session, response); err == nil {
+		t.Fatal("registration from other origin is accepted")
+	}
+}
+
+func TestLoginDetectsClonedAuthenticator(t *testing.T) {
+	rp := newTestRelyingParty(t)
+	authenticator := newSoftwareAuthenticator(t)
+	owner := core.PasskeyUser{ID: 42, Name: "dj_max"}
+
+	credential := register(t, rp, authenticator, owner, testOrigin)
+	credential.SignCount = 10
+	owner.Credentials = []core.PasskeyCredential{*credential}
+
+	session, response := authenticator.login(t, rp, &owner, testOrigin)
+
+	_, err := rp.FinishLogin(session, response, func(int) (*core.PasskeyUser, error) { return &owner, nil })
+	if !errors.Is(err, ErrClonedAuthenticator) {
+		t.Fatalf("got %v, want %v", err, ErrClonedAuthenticator)
+	}
+}
+
+func newTestRelyingParty(t *testing.T) core.PasskeyRelyingParty {
+	t.Helper()
+
+	rp, err := New(Config{
+		RPID:          testRPID,
+		RPDisplayName: "beatflow",
+		RPOrigins:     []string{testOrigin},
+		Timeout:       time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	return rp
+}
+
+func register(t *testing.T, rp core.PasskeyRelyingParty, authenticator *softwareAuthenticator, owner core.PasskeyUser, origin string) *core.PasskeyCredential {
+	t.Helper()
+
+	options, session, err := rp.BeginRegistration(owner)
+	if err != nil {
+		t.Fatalf("BeginRegistration: %v", err)
+	}
+
+	credential, err := rp.FinishRegistration(owner, session, authenticator.create(t, challengeOf(t, options), origin))
+	if err != nil {
+		t.Fatalf("FinishRegistration: %v", err)
+	}
+
+	return credential
+}
+
+// softwareAuthenticator is a platform authenticator with one P-256 credential and none attestation
+type softwareAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	signCount    uint32
+}
+
+func newSoftwareAuthenticator(t *testing.T) *softwareAuthenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	credentialID := make([]byte, 16)
+	if _, err := rand.Read(credentialID); err != nil {
+		t.Fatalf("generate credential id: %v", err)
+	}
+
+	return &softwareAuthenticator{key: key, credentialID: credentialID}
+}
+
+// create returns PublicKeyCredential json of navigator.credentials.create
+func (a *softwareAuthenticator) create(t *testing.T, challenge string, origin string) []byte {
+	t.Helper()
+
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  int64(webauthncose.P256),
+		XCoord: a.key.PublicKey.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.PublicKey.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+
+	authData := a.authenticatorData(flagUserPresent | flagUserVerified | flagAttested)
+	authData = append(authData, make([]byte, 16)...) // aaguid
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.credentialID)))
+	authData = append(authData, a.credentialID...)
+	authData = append(authData, publicKey...)
+
+	attestationObject, err := webauthncbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": authData,
+	})
+	if err != nil {
+		t.Fatalf("marshal attestation object: %v", err)
+	}
+
+	return a.credential(t, map[string]string{
+		"clientDataJSON":    encode(clientData(t, "webauthn.create", challenge, origin)),
+		"attestationObject": encode(attestationObject),
+	})
+}
+
+// login runs login ceremony and returns session and PublicKeyCredential json of navigator.credentials.get
+func (a *softwareAuthenticator) login(t *testing.T, rp core.PasskeyRelyingParty, owner *core.PasskeyUser, origin string) ([]byte, []byte) {
+	t.Helper()
+
+	options, session, err := rp.BeginLogin(owner, true)
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+
+	a.signCount++
+
+	authData := a.authenticatorData(flagUserPresent | flagUserVerified)
+	clientDataJSON := clientData(t, "webauthn.get", challengeOf(t, options), origin)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+
+	digest := sha256.Sum256(append(authData, clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatalf("sign assertion: %v", err)
+	}
+
+	return session, a.credential(t, map[string]string{
+		"clientDataJSON":    encode(clientDataJSON),
+		"authenticatorData": encode(authData),
+		"signature":         encode(signature),
+		"userHandle":        encode([]byte("42")),
+	})
+}
+
+func (a *softwareAuthenticator) authenticatorData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(testRPID))
+
+	data := append(rpIDHash[:], flags)
+
+	return binary.BigEndian.AppendUint32(data, a.signCount)
+}
+
+func (a *softwareAuthenticator) credential(t *testing.T, response map[string]string) []byte {
+	t.Helper()
+
+	credential, err := json.Marshal(map[string]any{
+		"id":       encode(a.credentialID),
+		"rawId":    encode(a.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		t.Fatalf("marshal credential: %v", err)
+	}
+
+	return credential
+}
+
+func clientData(t *testing.T, ceremony string, challenge string, origin string) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": challenge,
+		"origin":    origin,
+	})
+	if err != nil {
+		t.Fatalf("marshal client data: %v", err)
+	}
+
+	return data
+}
+
+// challengeOf returns base64url challenge of creation or request options
+func challengeOf(t *testing.T, options []byte) string {
+	t.Helper()
+
+	var parsed struct {
+		PublicKey struct {
+			Challenge string `json:"challenge"`
+		} `json:"publicKey"`
+	}
+	if err := json.Unmarshal(options, &parsed); err != nil || parsed.PublicKey.Challenge == "" {
+		t.Fatalf("options have no challenge: %s", options)
+	}
+
+	return parsed.PublicKey.Challenge
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
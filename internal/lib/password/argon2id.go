@@ -0,0 +1,137 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+type Argon2idParams struct {
+	// Memory in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+type argon2idScheme struct {
+	params Argon2idParams
+}
+
+// NewArgon2id checks params, argon2 panics on zero parallelism
+// and needs at least 8 KiB of memory per lane
+func NewArgon2id(params Argon2idParams) (Scheme, error) {
+	switch {
+	case params.Parallelism < 1:
+		return nil, fmt.Errorf("argon2id parallelism must be at least 1")
+	case params.Memory < 8*uint32(params.Parallelism):
+		return nil, fmt.Errorf("argon2id memory must be at least %d KiB", 8*uint32(params.Parallelism))
+	case params.Iterations < 1:
+		return nil, fmt.Errorf("argon2id iterations must be at least 1")
+	case params.SaltLength < 1 || params.KeyLength < 1:
+		return nil, fmt.Errorf("argon2id salt and key must not be empty")
+	}
+
+	return &argon2idScheme{params: params}, nil
+}
+
+// Hash returns PHC string: $argon2id$v=19$m=65536,t=3,p=2$salt$key
+func (s *argon2idScheme) Hash(password string) (string, error) {
+	salt := make([]byte, s.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, s.params.Iterations, s.params.Memory, s.params.Parallelism, s.params.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		s.params.Memory,
+		s.params.Iterations,
+		s.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (s *argon2idScheme) Verify(password string, hash string) error {
+	decoded, err := decodeArgon2id(hash)
+	if err != nil {
+		return err
+	}
+
+	if decoded.version != argon2.Version {
+		return fmt.Errorf("unsupported argon2 version %d", decoded.version)
+	}
+
+	params := decoded.params
+	key := argon2.IDKey([]byte(password), decoded.salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(key, decoded.key) != 1 {
+		return ErrMismatch
+	}
+
+	return nil
+}
+
+func (s *argon2idScheme) NeedsRehash(hash string) bool {
+	decoded, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+
+	return decoded.version != argon2.Version || decoded.params != s.params
+}
+
+func (s *argon2idScheme) Identifies(hash string) bool {
+	return strings.HasPrefix(hash, argon2idPrefix)
+}
+
+type argon2idHash struct {
+	version int
+	params  Argon2idParams
+	salt    []byte
+	key     []byte
+}
+
+func decodeArgon2id(hash string) (*argon2idHash, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, ErrMalformedHash
+	}
+
+	decoded := new(argon2idHash)
+
+	_, err := fmt.Sscanf(parts[2], "v=%d", &decoded.version)
+	if err != nil {
+		return nil, ErrMalformedHash
+	}
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &decoded.params.Memory, &decoded.params.Iterations, &decoded.params.Parallelism)
+	if err != nil {
+		return nil, ErrMalformedHash
+	}
+
+	decoded.salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, ErrMalformedHash
+	}
+
+	decoded.key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(decoded.key) == 0 {
+		return nil, ErrMalformedHash
+	}
+
+	decoded.params.SaltLength = uint32(len(decoded.salt))
+	decoded.params.KeyLength = uint32(len(decoded.key))
+
+	return decoded, nil
+}
@@ -0,0 +1,65 @@
+package password
+
+import (
+	"errors"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+)
+
+var (
+	ErrMismatch      = errors.New("password does not match hash")
+	ErrUnknownScheme = errors.New("unknown password hash scheme")
+	ErrMalformedHash = errors.New("malformed password hash")
+)
+
+// Scheme is a single hashing algorithm
+type Scheme interface {
+	core.PasswordHasher
+	// Identifies reports whether hash was made by the scheme
+	Identifies(hash string) bool
+}
+
+type hasher struct {
+	current Scheme
+	schemes []Scheme
+}
+
+// New hashes with current scheme and verifies hashes of every given scheme,
+// hashes of legacy schemes always need rehash
+func New(current Scheme, legacy ...Scheme) core.PasswordHasher {
+	return &hasher{
+		current: current,
+		schemes: append([]Scheme{current}, legacy...),
+	}
+}
+
+func (h *hasher) Hash(password string) (string, error) {
+	return h.current.Hash(password)
+}
+
+func (h *hasher) Verify(password string, hash string) error {
+	scheme := h.scheme(hash)
+	if scheme == nil {
+		return ErrUnknownScheme
+	}
+
+	return scheme.Verify(password, hash)
+}
+
+func (h *hasher) NeedsRehash(hash string) bool {
+	if !h.current.Identifies(hash) {
+		return true
+	}
+
+	return h.current.NeedsRehash(hash)
+}
+
+func (h *hasher) scheme(hash string) Scheme {
+	for _, scheme := range h.schemes {
+		if scheme.Identifies(hash) {
+			return scheme
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package password
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+)
+
+var ErrUnknownPepper = errors.New("unknown pepper version")
+
+type pepper struct {
+	current int
+	secrets map[int][]byte
+}
+
+// NewPepper creates pepper with secrets by version, current is used for new hashes,
+// older versions are kept to verify hashes until they are upgraded.
+// Current version 0 means that new hashes are not peppered
+func NewPepper(current int, secrets map[int][]byte) (core.Pepper, error) {
+	if current != 0 && len(secrets[current]) == 0 {
+		return nil, fmt.Errorf("no secret for current pepper version %d", current)
+	}
+
+	return &pepper{current: current, secrets: secrets}, nil
+}
+
+func (p *pepper) CurrentVersion() int {
+	return p.current
+}
+
+// Apply returns HMAC-SHA256 of the password keyed by pepper of the version,
+// result is encoded to be accepted by every hashing scheme
+func (p *pepper) Apply(version int, password string) (string, error) {
+	if version == 0 {
+		return password, nil
+	}
+
+	secret, ok := p.secrets[version]
+	if !ok {
+		return "", ErrUnknownPepper
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(password))
+
+	return base64.RawStdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// pepperFile is json with base64 encoded secrets by version:
+// {"current": 2, "secrets": {"1": "...", "2": "..."}}
+type pepperFile struct {
+	Current int               `json:"current"`
+	Secrets map[string]string `json:"secrets"`
+}
+
+// LoadPepper reads pepper from json file
+func LoadPepper(path string) (core.Pepper, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParsePepper(data)
+}
+
+// ParsePepper reads pepper from json, the format is the same as of pepper file
+func ParsePepper(data []byte) (core.Pepper, error) {
+	var file pepperFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	secrets := make(map[int][]byte, len(file.Secrets))
+	for versionText, encoded := range file.Secrets {
+		version, err := strconv.Atoi(versionText)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid pepper version %q", versionText)
+		}
+
+		secret, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret of pepper version %d: %w", version, err)
+		}
+
+		secrets[version] = secret
+	}
+
+	return NewPepper(file.Current, secrets)
+}
@@ -0,0 +1,44 @@
+package pwned
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type dir struct {
+	path string
+}
+
+// NewDir reads range files named by hash prefix, as downloaded from
+// range API, e.g. 21BD1 or 21BD1.txt with SUFFIX:COUNT lines
+func NewDir(path string) RangeSource {
+	return &dir{path: path}
+}
+
+func (d *dir) Range(ctx context.Context, prefix string) (map[string]int, error) {
+	if err := validPrefix(prefix); err != nil {
+		return nil, err
+	}
+
+	prefix = strings.ToUpper(prefix)
+
+	for _, name := range []string{prefix, prefix + ".txt"} {
+		f, err := os.Open(filepath.Join(d.path, name))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		defer f.Close()
+
+		return parseRange(f, 0)
+	}
+
+	// Missing range file means no breached hashes with the prefix
+	return map[string]int{}, nil
+}
@@ -0,0 +1,94 @@
+package pwned
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// prefixCount is the number of possible hash prefixes
+const prefixCount = 1 << (4 * PrefixLength)
+
+type File struct {
+	f *os.File
+	// offsets[p] is the offset of the first line with prefix p,
+	// lines of the prefix end at offsets[p+1]
+	offsets []int64
+}
+
+// OpenFile indexes file with HASH:COUNT lines ordered by hash,
+// only index is kept in memory and ranges are read from disk
+func OpenFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	offsets, err := buildIndex(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to index %s: %w", path, err)
+	}
+
+	return &File{f: f, offsets: offsets}, nil
+}
+
+func buildIndex(r io.Reader) ([]int64, error) {
+	offsets := make([]int64, prefixCount+1)
+
+	var offset int64
+	next := 0
+
+	reader := bufio.NewReaderSize(r, 1<<20)
+	for {
+		line, err := reader.ReadSlice('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		if len(line) >= PrefixLength {
+			prefix, parseErr := strconv.ParseUint(string(line[:PrefixLength]), 16, 32)
+			if parseErr != nil {
+				return nil, fmt.Errorf("malformed line at offset %d", offset)
+			}
+
+			if int(prefix) < next-1 {
+				return nil, fmt.Errorf("lines are not ordered by hash at offset %d", offset)
+			}
+
+			for ; next <= int(prefix); next++ {
+				offsets[next] = offset
+			}
+		}
+
+		offset += int64(len(line))
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	for ; next <= prefixCount; next++ {
+		offsets[next] = offset
+	}
+
+	return offsets, nil
+}
+
+func (f *File) Range(ctx context.Context, prefix string) (map[string]int, error) {
+	if err := validPrefix(prefix); err != nil {
+		return nil, err
+	}
+
+	p, _ := strconv.ParseUint(prefix, 16, 32)
+	start, end := f.offsets[p], f.offsets[p+1]
+
+	return parseRange(io.NewSectionReader(f.f, start, end-start), PrefixLength)
+}
+
+func (f *File) Close() error {
+	return f.f.Close()
+}
@@ -0,0 +1,157 @@
+package pwned
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/service/auth"
+)
+
+// breached passwords of the fixture, lowercase ones are written with lowercase hex
+var breached = []struct {
+	password  string
+	count     int
+	lowercase bool
+}{
+	{"Tr0ub4dor&3", 120, false},
+	{"beatflow-2019", 2, false},
+	{"letmein-now", 7, true},
+}
+
+func hashOf(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// writeFixture writes breached passwords ordered by hash with CRLF line endings
+func writeFixture(t *testing.T) string {
+	t.Helper()
+
+	var lines []string
+	for _, b := range breached {
+		hash := hashOf(b.password)
+		if b.lowercase {
+			hash = strings.ToLower(hash)
+		}
+		lines = append(lines, hash+":"+strconv.Itoa(b.count))
+	}
+
+	slices.SortFunc(lines, func(a, b string) int {
+		return strings.Compare(strings.ToUpper(a), strings.ToUpper(b))
+	})
+
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o600); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+
+	return path
+}
+
+func openFixture(t *testing.T) RangeSource {
+	t.Helper()
+
+	source, err := Open(writeFixture(t))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { source.(*File).Close() })
+
+	return source
+}
+
+func TestFileBreachCount(t *testing.T) {
+	checker := NewChecker(openFixture(t))
+
+	for _, b := range breached {
+		count, err := checker.BreachCount(context.Background(), b.password)
+		if err != nil {
+			t.Fatalf("BreachCount(%q): %v", b.password, err)
+		}
+
+		if count != b.count {
+			t.Errorf("BreachCount(%q) = %d, want %d", b.password, count, b.count)
+		}
+	}
+
+	count, err := checker.BreachCount(context.Background(), "correct horse battery staple")
+	if err != nil {
+		t.Fatalf("BreachCount: %v", err)
+	}
+
+	if count != 0 {
+		t.Errorf("BreachCount of missing password = %d, want 0", count)
+	}
+}
+
+func TestFileRangeLowercasePrefix(t *testing.T) {
+	source := openFixture(t)
+	hash := hashOf("letmein-now")
+
+	for _, prefix := range []string{hash[:PrefixLength], strings.ToLower(hash[:PrefixLength])} {
+		suffixes, err := source.Range(context.Background(), prefix)
+		if err != nil {
+			t.Fatalf("Range(%q): %v", prefix, err)
+		}
+
+		if suffixes[hash[PrefixLength:]] != 7 {
+			t.Errorf("Range(%q) = %v, want suffix %s with count 7", prefix, suffixes, hash[PrefixLength:])
+		}
+	}
+}
+
+func TestFileThreshold(t *testing.T) {
+	checker := NewChecker(openFixture(t))
+
+	tests := []struct {
+		threshold int
+		wantErr   bool
+	}{
+		{0, true},
+		{2, true},
+		{3, false},
+	}
+
+	for _, tt := range tests {
+		policy := auth.NewPasswordPolicy(core.PasswordPolicyConfig{BreachThreshold: tt.threshold}, checker)
+
+		err := policy.Validate(context.Background(), "password", "", "beatflow-2019")
+
+		var validationErr *core.ValidationError
+		if gotErr := errors.As(err, &validationErr); gotErr != tt.wantErr {
+			t.Errorf("threshold %d: Validate = %v, want violation %v", tt.threshold, err, tt.wantErr)
+		}
+	}
+}
+
+func TestBuildIndexRejectsUnsortedFile(t *testing.T) {
+	lines := []string{
+		hashOf("beatflow-2019") + ":2",
+		hashOf("Tr0ub4dor&3") + ":120",
+	}
+	if strings.Compare(lines[0], lines[1]) < 0 {
+		lines[0], lines[1] = lines[1], lines[0]
+	}
+
+	_, err := buildIndex(bytes.NewReader([]byte(strings.Join(lines, "\n") + "\n")))
+	if err == nil {
+		t.Fatal("buildIndex accepted lines not ordered by hash")
+	}
+}
+
+func TestBuildIndexRejectsMalformedLine(t *testing.T) {
+	_, err := buildIndex(strings.NewReader("not a hash\n"))
+	if err == nil {
+		t.Fatal("buildIndex accepted malformed line")
+	}
+}
@@ -0,0 +1,114 @@
+package pwned
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+)
+
+// PrefixLength is the length of SHA-1 prefix sent to range source,
+// the rest of the hash never leaves the checker
+const PrefixLength = 5
+
+// RangeSource returns hash suffixes with breach counts for SHA-1 prefix,
+// it follows Have I Been Pwned range API so remote source can be used as well
+type RangeSource interface {
+	Range(ctx context.Context, prefix string) (map[string]int, error)
+}
+
+type checker struct {
+	source RangeSource
+}
+
+func NewChecker(source RangeSource) core.BreachedPasswordChecker {
+	return &checker{source: source}
+}
+
+func (c *checker) BreachCount(ctx context.Context, password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes, err := c.source.Range(ctx, hash[:PrefixLength])
+	if err != nil {
+		return 0, err
+	}
+
+	return suffixes[hash[PrefixLength:]], nil
+}
+
+// Open opens local corpus, path is either directory of range files
+// or single file with full hashes ordered by hash
+func Open(path string) (RangeSource, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return NewDir(path), nil
+	}
+
+	return OpenFile(path)
+}
+
+// parseRange reads lines in HASH:COUNT format, skip is the number
+// of leading hash characters dropped from returned suffixes
+func parseRange(r io.Reader, skip int) (map[string]int, error) {
+	suffixes := make(map[string]int)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		hash, count, err := parseLine(line)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(hash) <= skip {
+			return nil, fmt.Errorf("malformed range line %q", line)
+		}
+
+		suffixes[hash[skip:]] = count
+	}
+
+	return suffixes, scanner.Err()
+}
+
+func parseLine(line string) (hash string, count int, err error) {
+	hash, countText, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("malformed range line %q", line)
+	}
+
+	count, err = strconv.Atoi(countText)
+	if err != nil {
+		return "", 0, fmt.Errorf("malformed range line %q", line)
+	}
+
+	return strings.ToUpper(hash), count, nil
+}
+
+func validPrefix(prefix string) error {
+	if len(prefix) != PrefixLength {
+		return errors.New("invalid hash prefix")
+	}
+
+	if _, err := strconv.ParseUint(prefix, 16, 32); err != nil {
+		return errors.New("invalid hash prefix")
+	}
+
+	return nil
+}
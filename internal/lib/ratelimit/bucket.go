@@ -0,0 +1,43 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+)
+
+// Bucket is a token bucket, it is refilled continuously
+// with limit.Requests tokens per limit.Period
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+func NewBucket(limit core.RateLimit, now time.Time) Bucket {
+	return Bucket{
+		Tokens:    float64(limit.Requests),
+		UpdatedAt: now,
+	}
+}
+
+// Take takes one token, if there is none it returns time until one is added
+func (b *Bucket) Take(limit core.RateLimit, now time.Time) (bool, time.Duration) {
+	rate := float64(limit.Requests) / limit.Period.Seconds()
+
+	elapsed := max(now.Sub(b.UpdatedAt).Seconds(), 0)
+	b.Tokens = min(float64(limit.Requests), b.Tokens+elapsed*rate)
+	b.UpdatedAt = now
+
+	if b.Tokens >= 1 {
+		b.Tokens--
+		return true, 0
+	}
+
+	return false, time.Duration((1 - b.Tokens) / rate * float64(time.Second))
+}
+
+// Full reports whether the bucket is refilled completely by now,
+// such bucket is the same as a new one and need not be kept
+func (b *Bucket) Full(limit core.RateLimit, now time.Time) bool {
+	return now.Sub(b.UpdatedAt) >= limit.Period
+}
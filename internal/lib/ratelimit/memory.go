@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+)
+
+// sweepEvery is the number of calls after which full buckets are removed
+const sweepEvery = 1024
+
+type entry struct {
+	bucket Bucket
+	limit  core.RateLimit
+}
+
+type memory struct {
+	mu      sync.Mutex
+	buckets map[string]*entry
+	calls   int
+}
+
+// NewMemory keeps buckets in process, limits are not shared between replicas
+func NewMemory() core.RateLimiter {
+	return &memory{buckets: make(map[string]*entry)}
+}
+
+func (m *memory) Allow(ctx context.Context, key string, limit core.RateLimit) (bool, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	m.calls++
+	if m.calls%sweepEvery == 0 {
+		m.sweep(now)
+	}
+
+	e, ok := m.buckets[key]
+	if !ok {
+		e = &entry{bucket: NewBucket(limit, now), limit: limit}
+		m.buckets[key] = e
+	}
+
+	allowed, retryAfter := e.bucket.Take(limit, now)
+
+	return allowed, retryAfter, nil
+}
+
+func (m *memory) sweep(now time.Time) {
+	for key, e := range m.buckets {
+		if e.bucket.Full(e.limit, now) {
+			delete(m.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+)
+
+var ErrMalformedCiphertext = errors.New("malformed ciphertext")
+
+type box struct {
+	aead cipher.AEAD
+}
+
+// New encrypts with AES-256-GCM, key must be 32 bytes
+func New(key []byte) (core.SecretCipher, error) {
+	if len(key) != 32 {
+		return nil, errors.New("secret encryption key must be 32 bytes")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &box{aead: aead}, nil
+}
+
+// LoadKey reads base64 encoded key from file
+func LoadKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+}
+
+// Encrypt returns base64 of nonce followed by ciphertext, associated data
+// is not stored but has to be the same on decryption
+func (b *box) Encrypt(plaintext []byte, associatedData []byte) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := b.aead.Seal(nonce, nonce, plaintext, associatedData)
+
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (b *box) Decrypt(ciphertext string, associatedData []byte) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil || len(sealed) < b.aead.NonceSize() {
+		return nil, ErrMalformedCiphertext
+	}
+
+	nonce, sealed := sealed[:b.aead.NonceSize()], sealed[b.aead.NonceSize():]
+
+	return b.aead.Open(nil, nonce, sealed, associatedData)
+}
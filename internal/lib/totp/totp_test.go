@@ -0,0 +1,77 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 secret of RFC 6238 Appendix B
+var rfcSecret = []byte("12345678901234567890")
+
+// Codes of RFC 6238 Appendix B have 8 digits, their last 6 digits are compared
+func TestCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		step := Step(time.Unix(tt.unix, 0))
+		if got := Code(rfcSecret, step); got != tt.want {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := Step(now)
+
+	tests := []struct {
+		name   string
+		delta  int64
+		skew   int
+		wantOK bool
+	}{
+		{"current step", 0, 0, true},
+		{"previous step without skew", -1, 0, false},
+		{"previous step", -1, 1, true},
+		{"next step", 1, 1, true},
+		{"two steps behind", -2, 1, false},
+		{"two steps ahead", 2, 1, false},
+		{"two steps behind with wider skew", -2, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := Code(rfcSecret, current+tt.delta)
+
+			step, ok := Validate(rfcSecret, code, now, tt.skew)
+			if ok != tt.wantOK {
+				t.Fatalf("Validate ok = %v, want %v", ok, tt.wantOK)
+			}
+
+			if ok && step != current+tt.delta {
+				t.Errorf("Validate step = %d, want %d", step, current+tt.delta)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsMalformedCode(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code := Code(rfcSecret, Step(now))
+
+	for _, malformed := range []string{"", code[:Digits-1], code + "0", "abcdef"} {
+		if _, ok := Validate(rfcSecret, malformed, now, 1); ok {
+			t.Errorf("Validate accepted %q", malformed)
+		}
+	}
+}
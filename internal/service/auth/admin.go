@@ -0,0 +1,227 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/canonical"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/logger"
+)
+
+const (
+	defaultUserPageSize = 50
+	maxUserPageSize     = 100
+)
+
+// ListUsers pages users by id, page token is the id of the last user of the previous page
+func (s *service) ListUsers(ctx context.Context, filter core.UserFilter) (*core.UserPage, error) {
+	pageSize := filter.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultUserPageSize
+	} else if pageSize > maxUserPageSize {
+		pageSize = maxUserPageSize
+	}
+
+	var afterID int
+	if filter.PageToken != "" {
+		var err error
+		afterID, err = strconv.Atoi(filter.PageToken)
+		if err != nil || afterID < 0 {
+			return nil, core.ErrInvalidPageToken
+		}
+	}
+
+	// Canonical form of emails is their lower case
+	query := canonical.Username(strings.TrimSpace(filter.Query))
+
+	// One more user tells whether there is a next page
+	users, err := s.userStorage.ListUsers(ctx, query, filter.Role, afterID, pageSize+1)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	page := &core.UserPage{Users: users}
+	if len(users) > pageSize {
+		page.Users = users[:pageSize]
+		page.NextPageToken = strconv.Itoa(page.Users[pageSize-1].ID)
+	}
+
+	return page, nil
+}
+
+func (s *service) GetUser(ctx context.Context, userID int) (*core.User, error) {
+	user, err := s.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	user.Roles, _, err = s.roleStorage.GetUserRoles(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// DisableUser denies new logins of the user and logs out all its sessions
+func (s *service) DisableUser(ctx context.Context, actorID int, userID int, reason string) (err error) {
+	defer func() { s.audit(ctx, core.AuditUserDisabled, actorID, userID, reason, err) }()
+
+	if actorID == userID {
+		return core.ErrSelfAdminAction
+	}
+
+	err = s.userStorage.SetUserDisabled(ctx, userID, true)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	return s.revokeAllSessions(ctx, userID)
+}
+
+func (s *service) EnableUser(ctx context.Context, actorID int, userID int) (err error) {
+	defer func() { s.audit(ctx, core.AuditUserEnabled, actorID, userID, "", err) }()
+
+	err = s.userStorage.SetUserDisabled(ctx, userID, false)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// ForcePasswordReset denies password logins of the user until it resets password,
+// all sessions are logged out and reset token is sent to the user if it has address
+func (s *service) ForcePasswordReset(ctx context.Context, actorID int, userID int) (sent bool, err error) {
+	var details string
+	defer func() { s.audit(ctx, core.AuditPasswordResetForced, actorID, userID, details, err) }()
+
+	err = s.userStorage.SetPasswordResetRequired(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return false, err
+	}
+
+	err = s.revokeAllSessions(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	user, err := s.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return false, err
+	}
+
+	err = s.sendPasswordReset(ctx, *user)
+	if err != nil {
+		// Reset is forced anyway, the user has to ask support for the token
+		if errors.Is(err, core.ErrNoRecipient) {
+			details = "reset token not sent: " + err.Error()
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// AssignRole gives the role to the user, it reaches tokens on their next refresh
+func (s *service) AssignRole(ctx context.Context, actorID int, userID int, role string) (err error) {
+	defer func() { s.audit(ctx, core.AuditRoleAssigned, actorID, userID, role, err) }()
+
+	_, err = s.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	err = s.roleStorage.AddUserRole(ctx, userID, role)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// RemoveRole takes the role from the user, access tokens of the user are revoked,
+// so permissions of the role can not be used until tokens are refreshed
+func (s *service) RemoveRole(ctx context.Context, actorID int, userID int, role string) (err error) {
+	defer func() { s.audit(ctx, core.AuditRoleRemoved, actorID, userID, role, err) }()
+
+	if actorID == userID && role == core.RoleAdmin {
+		return core.ErrSelfAdminAction
+	}
+
+	_, err = s.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	err = s.roleStorage.RemoveUserRole(ctx, userID, role)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	err = s.revocation.RevokeAllTokens(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// UnlockUser removes lock and forgets failed logins of the user and of the ip if it is given
+func (s *service) UnlockUser(ctx context.Context, actorID int, userID int, ip string) (err error) {
+	defer func() { s.audit(ctx, core.AuditUserUnlocked, actorID, userID, ip, err) }()
+
+	_, err = s.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	err = s.lockout.Unlock(ctx, userID, ip)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// DeleteUser deletes the user with everything that belongs to it
+func (s *service) DeleteUser(ctx context.Context, actorID int, userID int) (err error) {
+	defer func() { s.audit(ctx, core.AuditUserDeleted, actorID, userID, "", err) }()
+
+	if actorID == userID {
+		return core.ErrSelfAdminAction
+	}
+
+	// Tokens are revoked first, so this replica rejects them without database
+	err = s.revocation.RevokeAllTokens(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	err = s.userStorage.DeleteUser(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/logger"
+)
+
+const (
+	defaultAuditPageSize = 100
+	maxAuditPageSize     = 500
+
+	maxRequestIDLength    = 64
+	maxAuditDetailsLength = 255
+)
+
+// ListAuditEvents pages events from the newest, page token is the id
+// of the last event of the previous page
+func (s *service) ListAuditEvents(ctx context.Context, filter core.AuditFilter) (*core.AuditPage, error) {
+	pageSize := filter.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultAuditPageSize
+	} else if pageSize > maxAuditPageSize {
+		pageSize = maxAuditPageSize
+	}
+
+	var beforeID int64
+	if filter.PageToken != "" {
+		var err error
+		beforeID, err = strconv.ParseInt(filter.PageToken, 10, 64)
+		if err != nil || beforeID <= 0 {
+			return nil, core.ErrInvalidPageToken
+		}
+	}
+
+	// One more event tells whether there is a next page
+	events, err := s.auditStorage.GetAuditEvents(ctx, filter, beforeID, pageSize+1)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	page := &core.AuditPage{Events: events}
+	if len(events) > pageSize {
+		page.Events = events[:pageSize]
+		page.NextPageToken = strconv.FormatInt(page.Events[pageSize-1].ID, 10)
+	}
+
+	return page, nil
+}
+
+// audit records the event with outcome of err, error is the details of failed
+// event without them. The action is not failed if the event can not be recorded
+func (s *service) audit(ctx context.Context, eventType string, actorID int, targetID int, details string, err error) {
+	client := sessionClientInfo(ctx)
+
+	outcome := core.AuditSuccess
+	if err != nil {
+		outcome = core.AuditFailure
+		if details == "" {
+			details = err.Error()
+		}
+	}
+
+	auditErr := s.auditStorage.AddAuditEvent(ctx, core.AuditEvent{
+		Type:      eventType,
+		ActorID:   actorID,
+		TargetID:  targetID,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		RequestID: truncate(client.RequestID, maxRequestIDLength),
+		Outcome:   outcome,
+		Details:   truncate(details, maxAuditDetailsLength),
+	})
+	if auditErr != nil {
+		logger.Log().Error(ctx, auditErr.Error())
+	}
+}
@@ -2,81 +2,245 @@ package auth
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/canonical"
 	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/jwt"
 	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/logger"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/opaque"
 )
 
 type service struct {
-	userStorage core.UserStore
-	authConfig  core.AuthConfig
+	userStorage    core.UserStore
+	tokenStorage   core.RefreshTokenStore
+	resetStorage   core.PasswordResetStore
+	mfaStorage     core.MFAStore
+	passkeyStorage core.PasskeyStore
+	sessionStorage core.SessionStore
+	roleStorage    core.RoleStore
+	auditStorage   core.AuditStore
+	otpStorage     core.OTPStore
+	phoneStorage   core.PhoneVerificationStore
+	revocation     core.RevocationService
+	notifier       core.Notifier
+	smsNotifier    core.Notifier
+	policy         *PasswordPolicy
+	hasher         core.PasswordHasher
+	pepper         core.Pepper
+	lockout        *Lockout
+	cipher         core.SecretCipher
+	relyingParty   core.PasskeyRelyingParty
+	otpConfig      core.OTPConfig
+	authConfig     core.AuthConfig
+	keyring        *jwt.Keyring
+	verifier       *jwt.Verifier
+	mfaVerifier    *jwt.Verifier
+	emailVerifier  *jwt.Verifier
 }
 
-func NewConfig(secret string, tokenTTL int) core.AuthConfig {
+func NewConfig(
+	tokenTTL int,
+	refreshTokenTTL int,
+	issuer string,
+	audience []string,
+	passwordResetTTL int,
+	passwordResetURL string,
+	emailVerificationTTL int,
+	emailVerificationURL string,
+	requireVerifiedEmail bool,
+) core.AuthConfig {
 	return core.AuthConfig{
-		Secret:   secret,
-		TokenTTL: tokenTTL,
+		TokenTTL:         tokenTTL,
+		RefreshTokenTTL:  refreshTokenTTL,
+		Issuer:           issuer,
+		Audience:         audience,
+		PasswordResetTTL: passwordResetTTL,
+		PasswordResetURL: passwordResetURL,
+
+		EmailVerificationTTL: emailVerificationTTL,
+		EmailVerificationURL: emailVerificationURL,
+		RequireVerifiedEmail: requireVerifiedEmail,
 	}
 }
 
-func New(userStorage core.UserStore, authConfig core.AuthConfig) core.AuthService {
+func New(
+	userStorage core.UserStore,
+	tokenStorage core.RefreshTokenStore,
+	resetStorage core.PasswordResetStore,
+	mfaStorage core.MFAStore,
+	passkeyStorage core.PasskeyStore,
+	sessionStorage core.SessionStore,
+	roleStorage core.RoleStore,
+	auditStorage core.AuditStore,
+	otpStorage core.OTPStore,
+	phoneStorage core.PhoneVerificationStore,
+	revocation core.RevocationService,
+	notifier core.Notifier,
+	smsNotifier core.Notifier,
+	policy *PasswordPolicy,
+	hasher core.PasswordHasher,
+	pepper core.Pepper,
+	lockout *Lockout,
+	cipher core.SecretCipher,
+	relyingParty core.PasskeyRelyingParty,
+	otpConfig core.OTPConfig,
+	authConfig core.AuthConfig,
+	keyring *jwt.Keyring,
+	verifier *jwt.Verifier,
+) core.AuthService {
 	return &service{
-		userStorage: userStorage,
-		authConfig:  authConfig,
+		userStorage:    userStorage,
+		tokenStorage:   tokenStorage,
+		resetStorage:   resetStorage,
+		mfaStorage:     mfaStorage,
+		passkeyStorage: passkeyStorage,
+		sessionStorage: sessionStorage,
+		roleStorage:    roleStorage,
+		auditStorage:   auditStorage,
+		otpStorage:     otpStorage,
+		phoneStorage:   phoneStorage,
+		revocation:     revocation,
+		notifier:       notifier,
+		smsNotifier:    smsNotifier,
+		policy:         policy,
+		hasher:         hasher,
+		pepper:         pepper,
+		lockout:        lockout,
+		cipher:         cipher,
+		relyingParty:   relyingParty,
+		otpConfig:      otpConfig,
+		authConfig:     authConfig,
+		keyring:        keyring,
+		verifier:       verifier,
+		mfaVerifier:    verifier.WithAudience(mfaAudience(authConfig.Issuer)).WithUse(core.TokenUseMFA),
+		emailVerifier:  verifier.WithAudience(emailVerificationAudience(authConfig.Issuer)).WithUse(core.TokenUseEmailVerification),
 	}
 }
 
-func (s *service) Login(ctx context.Context, user core.User) (*string, error) {
-	userFromDB, err := s.userStorage.GetUserByUsername(ctx, user.Username)
+func (s *service) Login(ctx context.Context, user core.User) (*core.LoginResult, error) {
+	clientIP := core.ClientInfoFromContext(ctx).IP
+
+	err := s.lockout.CheckIP(ctx, clientIP)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	// Attempted identifier is audited, so attacks on unknown users are visible too
+	identifier := canonical.Username(user.Username)
+
+	userFromDB, err := s.findUser(ctx, user.Username)
 	if err != nil {
 		logger.Log().Error(ctx, err.Error())
+		if errors.Is(err, core.ErrInvalidCredentials) {
+			s.loginFailed(ctx, 0, identifier, clientIP)
+		}
 		return nil, err
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(userFromDB.PasswordHash), []byte(user.PasswordHash))
+	err = s.lockout.CheckUser(ctx, userFromDB.ID)
 	if err != nil {
 		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	err = s.verifyPassword(*userFromDB, user.PasswordHash)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		s.loginFailed(ctx, userFromDB.ID, identifier, clientIP)
 		return nil, core.ErrInvalidCredentials
 	}
 
-	token, err := jwt.GenerateToken(userFromDB.ID, s.authConfig)
+	// Password is checked first, so the requirement is not revealed to others
+	if userFromDB.PasswordResetRequired {
+		return nil, core.ErrPasswordResetRequired
+	}
+
+	if s.needsRehash(*userFromDB) {
+		s.rehashPassword(ctx, *userFromDB, user.PasswordHash)
+	}
+
+	return s.finishLogin(ctx, userFromDB.ID)
+}
+
+func (s *service) Refresh(ctx context.Context, refreshToken string) (*core.TokenPair, error) {
+	token, err := s.tokenStorage.GetRefreshToken(ctx, opaque.Hash(refreshToken))
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	if token.UsedAt != nil || token.RevokedAt != nil {
+		return nil, s.revokeFamily(ctx, token.UserID, token.FamilyID)
+	}
+
+	if time.Now().After(token.ExpiresAt) {
+		return nil, core.ErrInvalidRefreshToken
+	}
+
+	err = s.tokenStorage.UseRefreshToken(ctx, token.ID)
 	if err != nil {
+		if errors.Is(err, core.ErrRefreshTokenReused) {
+			return nil, s.revokeFamily(ctx, token.UserID, token.FamilyID)
+		}
 		logger.Log().Error(ctx, err.Error())
 		return nil, err
 	}
 
-	return token, nil
+	s.touchSession(ctx, token.FamilyID)
+
+	return s.issueTokens(ctx, token.UserID, token.FamilyID)
 }
 
-func (s *service) Signup(ctx context.Context, user core.User) error {
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(user.PasswordHash), bcrypt.DefaultCost)
+func (s *service) Logout(ctx context.Context, claims core.Claims) (err error) {
+	defer func() { s.audit(ctx, core.AuditTokenRevoked, claims.UserID, claims.UserID, claims.SessionID, err) }()
+
+	err = s.revocation.RevokeToken(ctx, claims)
 	if err != nil {
 		logger.Log().Error(ctx, err.Error())
 		return err
 	}
 
-	user.PasswordHash = string(passwordHash)
+	err = s.tokenStorage.RevokeRefreshTokenFamily(ctx, claims.SessionID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
 
-	_, err = s.userStorage.AddUser(ctx, user)
+	err = s.endSession(ctx, claims.UserID, claims.SessionID)
 	if err != nil {
+		logger.Log().Error(ctx, err.Error())
 		return err
 	}
 
 	return nil
 }
 
-func (s *service) UpdatePassword(ctx context.Context, user core.User) error {
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(user.PasswordHash), bcrypt.DefaultCost)
+func (s *service) LogoutAll(ctx context.Context, userID int) (err error) {
+	defer func() { s.audit(ctx, core.AuditSessionsRevoked, userID, userID, "", err) }()
+
+	return s.revokeAllSessions(ctx, userID)
+}
+
+// revokeAllSessions logs out every session of the user
+func (s *service) revokeAllSessions(ctx context.Context, userID int) error {
+	err := s.revocation.RevokeAllTokens(ctx, userID)
 	if err != nil {
 		logger.Log().Error(ctx, err.Error())
 		return err
 	}
 
-	user.PasswordHash = string(passwordHash)
+	err = s.tokenStorage.RevokeUserRefreshTokens(ctx, userID, "")
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
 
-	_, err = s.userStorage.UpdateUser(ctx, user)
+	err = s.sessionStorage.RevokeUserSessions(ctx, userID, "")
 	if err != nil {
 		logger.Log().Error(ctx, err.Error())
 		return err
@@ -84,3 +248,331 @@ func (s *service) UpdatePassword(ctx context.Context, user core.User) error {
 
 	return nil
 }
+
+func (s *service) GetJWKS(ctx context.Context) []core.JWK {
+	return s.keyring.JWKS()
+}
+
+// Introspect returns claims of active token, revoked tokens are not active
+func (s *service) Introspect(ctx context.Context, token string) (*core.Claims, error) {
+	claims, err := s.verifier.Verify(token)
+	if err != nil {
+		logger.Log().Debug(ctx, err.Error())
+		return nil, core.ErrInactiveToken
+	}
+
+	revoked, err := s.revocation.IsRevoked(ctx, *claims)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	if revoked {
+		return nil, core.ErrInactiveToken
+	}
+
+	return claims, nil
+}
+
+func (s *service) Signup(ctx context.Context, user core.User) error {
+	user.Username = strings.TrimSpace(user.Username)
+	user.CanonicalUsername = canonical.Username(user.Username)
+
+	err := canonical.ValidateUsername(user.CanonicalUsername)
+	if err != nil {
+		return &core.ValidationError{Violations: []core.FieldViolation{{
+			Field:       "username",
+			Description: usernameViolation(err),
+		}}}
+	}
+
+	err = s.policy.Validate(ctx, "password", user.Username, user.PasswordHash)
+	if err != nil {
+		return err
+	}
+
+	// New emails start unverified and new users are listeners
+	user.EmailVerified = false
+	user.Roles = []string{core.RoleListener}
+	if user.Email != "" {
+		user.Email, err = normalizeEmail("email", user.Email)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.setPassword(&user, user.PasswordHash)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	user.ID, err = s.userStorage.AddUser(ctx, user)
+	s.audit(ctx, core.AuditSignup, user.ID, user.ID, user.CanonicalUsername, err)
+	if err != nil {
+		return err
+	}
+
+	// The user can request another email, so signup is not failed
+	if user.Email != "" {
+		err = s.sendVerificationEmail(ctx, user)
+		if err != nil {
+			logger.Log().Error(ctx, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// UpdatePassword changes password of the caller, all other sessions are revoked,
+// token of the caller is revoked too, so a new one is returned
+func (s *service) UpdatePassword(ctx context.Context, claims core.Claims, oldPassword string, newPassword string) (token *string, err error) {
+	defer func() { s.audit(ctx, core.AuditPasswordChanged, claims.UserID, claims.UserID, "", err) }()
+
+	user, err := s.userStorage.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	err = s.verifyPassword(*user, oldPassword)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, core.ErrInvalidCredentials
+	}
+
+	err = s.policy.Validate(ctx, "newPassword", user.Username, newPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.verifyPassword(*user, newPassword) == nil {
+		return nil, core.ErrSamePassword
+	}
+
+	err = s.setPassword(user, newPassword)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	_, err = s.userStorage.UpdateUser(ctx, *user)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	err = s.revocation.RevokeAllTokens(ctx, user.ID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	err = s.tokenStorage.RevokeUserRefreshTokens(ctx, user.ID, claims.SessionID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	err = s.sessionStorage.RevokeUserSessions(ctx, user.ID, claims.SessionID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return s.issueAccessToken(ctx, user.ID, claims.SessionID)
+}
+
+// findUser finds the user by username or verified email, emails of other
+// users are not matched, so an unverified email can not take over login
+func (s *service) findUser(ctx context.Context, identifier string) (*core.User, error) {
+	if strings.Contains(identifier, "@") {
+		user, err := s.userStorage.GetUserByEmail(ctx, strings.TrimSpace(identifier))
+		if err == nil && user.EmailVerified {
+			return user, nil
+		}
+		if err != nil && !errors.Is(err, core.ErrInvalidCredentials) {
+			return nil, err
+		}
+	}
+
+	// Usernames created before canonicalization may look like emails
+	return s.userStorage.GetUserByUsername(ctx, canonical.Username(identifier))
+}
+
+// finishLogin is called once the first factor is passed, it issues tokens
+// or mfa token if the user has second factor enabled
+func (s *service) finishLogin(ctx context.Context, userID int) (*core.LoginResult, error) {
+	mfaRequired, err := s.mfaRequired(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	// Failures are kept until the second factor is passed too,
+	// otherwise the password would reset attempts of guessing the code
+	if mfaRequired {
+		mfaToken, err := s.issueMFAToken(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &core.LoginResult{MFAToken: *mfaToken}, nil
+	}
+
+	err = s.lockout.Succeed(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+	}
+
+	tokens, err := s.startSession(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &core.LoginResult{Tokens: tokens}, nil
+}
+
+// loginFailed records failed login, the caller gets invalid credentials
+// even if failure can not be recorded
+func (s *service) loginFailed(ctx context.Context, userID int, identifier string, clientIP string) {
+	var details string
+	if identifier != "" {
+		details = core.ErrInvalidCredentials.Error() + ": " + identifier
+	}
+
+	s.audit(ctx, core.AuditLogin, userID, userID, details, core.ErrInvalidCredentials)
+
+	err := s.lockout.Fail(ctx, userID, clientIP)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+	}
+}
+
+// rehashPassword upgrades hash of the user to current hasher settings and pepper,
+// login is not failed if it does not succeed
+func (s *service) rehashPassword(ctx context.Context, user core.User, password string) {
+	err := s.setPassword(&user, password)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return
+	}
+
+	_, err = s.userStorage.UpdateUser(ctx, user)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+	}
+}
+
+func (s *service) issueTokens(ctx context.Context, userID int, familyID string) (*core.TokenPair, error) {
+	accessToken, err := s.issueAccessToken(ctx, userID, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := opaque.NewToken()
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	_, err = s.tokenStorage.AddRefreshToken(ctx, core.RefreshToken{
+		UserID:    userID,
+		TokenHash: opaque.Hash(refreshToken),
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(time.Hour * time.Duration(s.authConfig.RefreshTokenTTL)),
+	})
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return &core.TokenPair{
+		AccessToken:  *accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+func (s *service) issueAccessToken(ctx context.Context, userID int, familyID string) (*string, error) {
+	user, err := s.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	if s.authConfig.RequireVerifiedEmail && user.Email != "" && !user.EmailVerified {
+		return nil, core.ErrEmailNotVerified
+	}
+
+	// Roles are read on every refresh, so changes reach tokens within token ttl
+	roles, permissions, err := s.roleStorage.GetUserRoles(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	generation, err := s.revocation.TokenGeneration(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	tokenID, err := opaque.NewID()
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	now := time.Now()
+
+	accessToken, err := jwt.GenerateToken(core.Claims{
+		ID:            tokenID,
+		Use:           core.TokenUseAccess,
+		UserID:        userID,
+		SessionID:     familyID,
+		Generation:    generation,
+		Issuer:        s.authConfig.Issuer,
+		Audience:      s.authConfig.Audience,
+		Roles:         roles,
+		Permissions:   permissions,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		IssuedAt:      now,
+		ExpiresAt:     now.Add(time.Minute * time.Duration(s.authConfig.TokenTTL)),
+	}, s.keyring)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return accessToken, nil
+}
+
+// revokeFamily is called when already used refresh token is presented again,
+// it means that the token was stolen, so the whole family is revoked
+func (s *service) revokeFamily(ctx context.Context, userID int, familyID string) (err error) {
+	logger.Log().Warn(ctx, "refresh token reuse detected, revoking family %s", familyID)
+
+	defer func() { s.audit(ctx, core.AuditTokenReused, 0, userID, "family "+familyID, err) }()
+
+	err = s.tokenStorage.RevokeRefreshTokenFamily(ctx, familyID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	return core.ErrRefreshTokenReused
+}
+
+func usernameViolation(err error) string {
+	switch {
+	case errors.Is(err, canonical.ErrUsernameLength):
+		return fmt.Sprintf("must be from %d to %d characters long", canonical.MinUsernameLength, canonical.MaxUsernameLength)
+	case errors.Is(err, canonical.ErrUsernameMixedScript):
+		return "must not mix letters of different scripts"
+	case errors.Is(err, canonical.ErrUsernameScript):
+		return "may contain only latin, chinese, japanese and korean letters"
+	default:
+		return "may contain only letters, digits, underscores, dots and hyphens"
+	}
+}
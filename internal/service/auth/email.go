@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/jwt"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/logger"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/opaque"
+)
+
+// SendVerificationEmail sends link with signed token to the email of the user
+func (s *service) SendVerificationEmail(ctx context.Context, userID int) error {
+	user, err := s.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	if user.Email == "" {
+		return core.ErrNoEmail
+	}
+
+	if user.EmailVerified {
+		return core.ErrEmailAlreadyVerified
+	}
+
+	return s.sendVerificationEmail(ctx, *user)
+}
+
+// VerifyEmail marks email from the token as verified, token stops
+// working once the user has another email
+func (s *service) VerifyEmail(ctx context.Context, token string) error {
+	claims, err := s.emailVerifier.Verify(token)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return core.ErrInvalidVerificationToken
+	}
+
+	user, err := s.userStorage.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	if !strings.EqualFold(user.Email, claims.Email) {
+		return core.ErrInvalidVerificationToken
+	}
+
+	if user.EmailVerified {
+		return nil
+	}
+
+	err = s.userStorage.SetEmailVerified(ctx, user.ID, claims.Email)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (s *service) sendVerificationEmail(ctx context.Context, user core.User) error {
+	token, err := s.issueVerificationToken(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	err = s.notifier.Notify(ctx, core.Notification{
+		UserID:   user.ID,
+		Username: user.Username,
+		To:       user.Email,
+		Subject:  "Email verification",
+		Body:     s.verificationEmailBody(*token),
+	})
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// issueVerificationToken issues token signed with the same keys as access tokens,
+// its use and audience differ, so it is not accepted in their place
+func (s *service) issueVerificationToken(ctx context.Context, user core.User) (*string, error) {
+	tokenID, err := opaque.NewID()
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	now := time.Now()
+
+	token, err := jwt.GenerateToken(core.Claims{
+		ID:        tokenID,
+		Use:       core.TokenUseEmailVerification,
+		UserID:    user.ID,
+		Issuer:    s.authConfig.Issuer,
+		Audience:  []string{emailVerificationAudience(s.authConfig.Issuer)},
+		Email:     user.Email,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(time.Hour * time.Duration(s.authConfig.EmailVerificationTTL)),
+	}, s.keyring)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (s *service) verificationEmailBody(token string) string {
+	if s.authConfig.EmailVerificationURL == "" {
+		return fmt.Sprintf("Use this code to verify your email: %s", token)
+	}
+
+	return fmt.Sprintf("Follow the link to verify your email: %s?token=%s",
+		s.authConfig.EmailVerificationURL, url.QueryEscape(token))
+}
+
+func emailVerificationAudience(issuer string) string {
+	return issuer + "/email-verification"
+}
+
+// normalizeEmail returns lower case address without display name,
+// field is the name of request field that is reported in violations
+func normalizeEmail(field string, email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return "", &core.ValidationError{Violations: []core.FieldViolation{{
+			Field:       field,
+			Description: "must be a valid email address",
+		}}}
+	}
+
+	return email, nil
+}
+
+// notificationAddress returns email the notifications of the user
+// can be sent to, it is empty until the email is verified
+func notificationAddress(user core.User) string {
+	if !user.EmailVerified {
+		return ""
+	}
+
+	return user.Email
+}
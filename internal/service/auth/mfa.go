@@ -0,0 +1,310 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/jwt"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/logger"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/opaque"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/totp"
+)
+
+const (
+	// mfaTokenTTL is the time the user has to enter the second factor
+	mfaTokenTTL = 5 * time.Minute
+	// totpSkew is the number of time steps accepted around current one
+	totpSkew          = 1
+	recoveryCodeCount = 10
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// EnrollTOTP generates new secret of the user, it is not used
+// until the user confirms it with a code from authenticator app
+func (s *service) EnrollTOTP(ctx context.Context, userID int) (*core.TOTPEnrollment, error) {
+	if s.cipher == nil {
+		return nil, core.ErrMFANotConfigured
+	}
+
+	user, err := s.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	encrypted, err := s.cipher.Encrypt(secret, totpAssociatedData(userID))
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	err = s.mfaStorage.SetTOTP(ctx, core.TOTP{
+		UserID: userID,
+		Secret: encrypted,
+	})
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return &core.TOTPEnrollment{
+		Secret: totp.EncodeSecret(secret),
+		URI:    totp.URI(s.authConfig.Issuer, user.Username, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables the second factor, recovery codes are returned
+// only once, just their hashes are stored
+func (s *service) ConfirmTOTP(ctx context.Context, userID int, code string) ([]string, error) {
+	userTOTP, err := s.mfaStorage.GetTOTP(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	if userTOTP.ConfirmedAt != nil {
+		return nil, core.ErrMFAAlreadyEnabled
+	}
+
+	step, err := s.validateTOTP(userTOTP, code)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	recoveryCodes := make([]string, 0, recoveryCodeCount)
+	recoveryCodeHashes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		recoveryCode, err := newRecoveryCode()
+		if err != nil {
+			logger.Log().Error(ctx, err.Error())
+			return nil, err
+		}
+
+		recoveryCodes = append(recoveryCodes, recoveryCode)
+		recoveryCodeHashes = append(recoveryCodeHashes, opaque.Hash(normalizeRecoveryCode(recoveryCode)))
+	}
+
+	err = s.mfaStorage.ConfirmTOTP(ctx, userID, step, recoveryCodeHashes)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+// DisableTOTP removes the second factor, password is required,
+// so a stolen access token is not enough to turn it off
+func (s *service) DisableTOTP(ctx context.Context, userID int, password string) error {
+	user, err := s.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	err = s.verifyPassword(*user, password)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return core.ErrInvalidCredentials
+	}
+
+	err = s.mfaStorage.DeleteTOTP(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// VerifyMFA completes login started with password, either code
+// from authenticator app or one of recovery codes is accepted
+func (s *service) VerifyMFA(ctx context.Context, mfaToken string, code string, recoveryCode string) (*core.TokenPair, error) {
+	claims, err := s.verifyMFAToken(ctx, mfaToken)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	err = s.lockout.CheckUser(ctx, claims.UserID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	switch {
+	case code != "":
+		err = s.useTOTP(ctx, claims.UserID, code)
+	case recoveryCode != "":
+		err = s.mfaStorage.UseRecoveryCode(ctx, claims.UserID, opaque.Hash(normalizeRecoveryCode(recoveryCode)))
+	default:
+		err = core.ErrInvalidMFACode
+	}
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		if errors.Is(err, core.ErrInvalidMFACode) {
+			s.loginFailed(ctx, claims.UserID, "", core.ClientInfoFromContext(ctx).IP)
+		}
+		return nil, err
+	}
+
+	// Challenge is completed, so it can not be used again
+	err = s.revocation.RevokeToken(ctx, *claims)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	err = s.lockout.Succeed(ctx, claims.UserID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+	}
+
+	return s.startSession(ctx, claims.UserID)
+}
+
+// mfaRequired reports whether the user has confirmed second factor
+func (s *service) mfaRequired(ctx context.Context, userID int) (bool, error) {
+	userTOTP, err := s.mfaStorage.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, core.ErrMFANotEnrolled) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return userTOTP.ConfirmedAt != nil, nil
+}
+
+// verifyMFAToken returns claims of mfa token that is neither expired nor used
+func (s *service) verifyMFAToken(ctx context.Context, mfaToken string) (*core.Claims, error) {
+	claims, err := s.mfaVerifier.Verify(mfaToken)
+	if err != nil {
+		return nil, core.ErrInvalidMFAToken
+	}
+
+	revoked, err := s.revocation.IsRevoked(ctx, *claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if revoked {
+		return nil, core.ErrInvalidMFAToken
+	}
+
+	return claims, nil
+}
+
+// issueMFAToken issues short lived token for VerifyMFA, its use and audience
+// differ from access tokens, so it is not accepted in their place
+func (s *service) issueMFAToken(ctx context.Context, userID int) (*string, error) {
+	generation, err := s.revocation.TokenGeneration(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	tokenID, err := opaque.NewID()
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	now := time.Now()
+
+	mfaToken, err := jwt.GenerateToken(core.Claims{
+		ID:         tokenID,
+		Use:        core.TokenUseMFA,
+		UserID:     userID,
+		Generation: generation,
+		Issuer:     s.authConfig.Issuer,
+		Audience:   []string{mfaAudience(s.authConfig.Issuer)},
+		IssuedAt:   now,
+		ExpiresAt:  now.Add(mfaTokenTTL),
+	}, s.keyring)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return mfaToken, nil
+}
+
+func (s *service) useTOTP(ctx context.Context, userID int, code string) error {
+	userTOTP, err := s.mfaStorage.GetTOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if userTOTP.ConfirmedAt == nil {
+		return core.ErrMFANotEnrolled
+	}
+
+	step, err := s.validateTOTP(userTOTP, code)
+	if err != nil {
+		return err
+	}
+
+	return s.mfaStorage.UseTOTPStep(ctx, userID, step)
+}
+
+func (s *service) validateTOTP(userTOTP *core.TOTP, code string) (int64, error) {
+	if s.cipher == nil {
+		return 0, core.ErrMFANotConfigured
+	}
+
+	secret, err := s.cipher.Decrypt(userTOTP.Secret, totpAssociatedData(userTOTP.UserID))
+	if err != nil {
+		return 0, err
+	}
+
+	step, ok := totp.Validate(secret, strings.TrimSpace(code), time.Now(), totpSkew)
+	if !ok || step <= userTOTP.LastUsedStep {
+		return 0, core.ErrInvalidMFACode
+	}
+
+	return step, nil
+}
+
+func mfaAudience(issuer string) string {
+	return issuer + "/mfa"
+}
+
+// totpAssociatedData binds encrypted secret to the user,
+// so it can not be copied to another row
+func totpAssociatedData(userID int) []byte {
+	return []byte("totp:" + strconv.Itoa(userID))
+}
+
+// newRecoveryCode returns code like abcde-fghij
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))[:10]
+
+	return code[:5] + "-" + code[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/logger"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/opaque"
+)
+
+// StartOTPLogin sends one-time code to the user, login id is returned even
+// for unknown and locked usernames, so the method can not be used to enumerate accounts.
+// Codes are sent to verified email or phone of the user, depending on the channel
+func (s *service) StartOTPLogin(ctx context.Context, username string, channel string) (string, error) {
+	notifier, err := s.otpNotifier(channel)
+	if err != nil {
+		return "", err
+	}
+
+	loginID, err := opaque.NewID()
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return "", err
+	}
+
+	user, err := s.findUser(ctx, username)
+	if err != nil {
+		if errors.Is(err, core.ErrInvalidCredentials) {
+			logger.Log().Debug(ctx, "otp login for unknown user")
+			return loginID, nil
+		}
+		logger.Log().Error(ctx, err.Error())
+		return "", err
+	}
+
+	err = s.lockout.CheckUser(ctx, user.ID)
+	if err != nil {
+		// Locked users get the same response as unknown ones, nothing is sent
+		var retryErr *core.RetryError
+		if errors.As(err, &retryErr) {
+			logger.Log().Debug(ctx, "otp login for locked user")
+			s.audit(ctx, core.AuditLogin, user.ID, user.ID, "otp login not started", err)
+			return loginID, nil
+		}
+		logger.Log().Error(ctx, err.Error())
+		return "", err
+	}
+
+	code, err := newOTPCode(s.otpConfig.Length)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return "", err
+	}
+
+	err = s.otpStorage.AddOTPLogin(ctx, core.OTPLogin{
+		ID:        loginID,
+		UserID:    user.ID,
+		CodeHash:  otpCodeHash(loginID, code),
+		ExpiresAt: time.Now().Add(s.otpConfig.TTL),
+	})
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return "", err
+	}
+
+	err = notifier.Notify(ctx, core.Notification{
+		UserID:   user.ID,
+		Username: user.Username,
+		To:       otpAddress(*user, channel),
+		Subject:  "Login code",
+		Body: fmt.Sprintf("Your login code is %s, it expires in %d minutes",
+			code, int(s.otpConfig.TTL.Minutes())),
+	})
+	if err != nil {
+		// Users without address get the same response as unknown ones
+		if errors.Is(err, core.ErrNoRecipient) {
+			logger.Log().Debug(ctx, "otp login for user without address")
+			return loginID, nil
+		}
+		logger.Log().Error(ctx, err.Error())
+		return "", err
+	}
+
+	return loginID, nil
+}
+
+// CompleteOTPLogin checks the code, every wrong code counts as failed login
+func (s *service) CompleteOTPLogin(ctx context.Context, loginID string, code string) (*core.LoginResult, error) {
+	clientIP := core.ClientInfoFromContext(ctx).IP
+
+	err := s.lockout.CheckIP(ctx, clientIP)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	userID, err := s.otpStorage.UseOTPLogin(ctx, loginID, otpCodeHash(loginID, code), s.otpConfig.MaxAttempts)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		if errors.Is(err, core.ErrInvalidOTP) {
+			s.loginFailed(ctx, userID, "", clientIP)
+		}
+		return nil, err
+	}
+
+	err = s.lockout.CheckUser(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return s.finishLogin(ctx, userID)
+}
+
+// newOTPCode returns random code of decimal digits
+func newOTPCode(length int) (string, error) {
+	code := make([]byte, length)
+	for i := range code {
+		digit, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		code[i] = byte('0' + digit.Int64())
+	}
+
+	return string(code), nil
+}
+
+// otpCodeHash binds code to the login, so equal codes of
+// different logins do not have equal hashes
+func otpCodeHash(loginID string, code string) string {
+	return opaque.Hash(loginID + ":" + code)
+}
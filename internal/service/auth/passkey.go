@@ -0,0 +1,260 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/logger"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/opaque"
+)
+
+// PasskeySessionTTL is the time the user has to finish a ceremony
+const PasskeySessionTTL = 5 * time.Minute
+
+// BeginPasskeyRegistration starts registration of a passkey, the password
+// is checked again, so a passkey is added only by the owner of the account
+func (s *service) BeginPasskeyRegistration(ctx context.Context, userID int, password string) (*core.PasskeyChallenge, error) {
+	if s.relyingParty == nil {
+		return nil, core.ErrPasskeysNotConfigured
+	}
+
+	userFromDB, err := s.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	err = s.verifyPassword(*userFromDB, password)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, core.ErrInvalidCredentials
+	}
+
+	user, err := s.passkeyUser(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	options, session, err := s.relyingParty.BeginRegistration(*user)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return s.startPasskeySession(ctx, userID, core.PasskeyRegistration, options, session)
+}
+
+func (s *service) FinishPasskeyRegistration(ctx context.Context, userID int, sessionID string, name string, response []byte) error {
+	if s.relyingParty == nil {
+		return core.ErrPasskeysNotConfigured
+	}
+
+	session, err := s.passkeyStorage.UsePasskeySession(ctx, sessionID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	if session.Purpose != core.PasskeyRegistration || session.UserID != userID {
+		return core.ErrInvalidPasskeySession
+	}
+
+	user, err := s.passkeyUser(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	credential, err := s.relyingParty.FinishRegistration(*user, session.Data, response)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return core.ErrInvalidPasskeyResponse
+	}
+
+	credential.Name = name
+
+	err = s.passkeyStorage.AddPasskeyCredential(ctx, *credential)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (s *service) BeginPasskeyLogin(ctx context.Context, username string, mfaToken string) (*core.PasskeyChallenge, error) {
+	if s.relyingParty == nil {
+		return nil, core.ErrPasskeysNotConfigured
+	}
+
+	var (
+		user    *core.PasskeyUser
+		claims  *core.Claims
+		purpose = core.PasskeyLogin
+		err     error
+	)
+
+	switch {
+	case mfaToken != "":
+		claims, err = s.verifyMFAToken(ctx, mfaToken)
+		if err != nil {
+			logger.Log().Error(ctx, err.Error())
+			return nil, err
+		}
+
+		purpose = core.PasskeyMFA
+
+		user, err = s.passkeyUser(ctx, claims.UserID)
+		if err != nil {
+			logger.Log().Error(ctx, err.Error())
+			return nil, err
+		}
+	case username != "":
+		userFromDB, err := s.findUser(ctx, username)
+		if err != nil {
+			logger.Log().Error(ctx, err.Error())
+			return nil, err
+		}
+
+		user, err = s.passkeyUser(ctx, userFromDB.ID)
+		if err != nil {
+			logger.Log().Error(ctx, err.Error())
+			return nil, err
+		}
+	}
+
+	if user != nil && len(user.Credentials) == 0 {
+		return nil, core.ErrInvalidCredentials
+	}
+
+	// Token is exchanged for the ceremony, so it can not start another one
+	if claims != nil {
+		err = s.revocation.RevokeToken(ctx, *claims)
+		if err != nil {
+			logger.Log().Error(ctx, err.Error())
+			return nil, err
+		}
+	}
+
+	// Passkey that replaces password has to verify the user itself
+	options, session, err := s.relyingParty.BeginLogin(user, purpose == core.PasskeyLogin)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	var userID int
+	if user != nil {
+		userID = user.ID
+	}
+
+	return s.startPasskeySession(ctx, userID, purpose, options, session)
+}
+
+func (s *service) FinishPasskeyLogin(ctx context.Context, sessionID string, response []byte) (*core.TokenPair, error) {
+	if s.relyingParty == nil {
+		return nil, core.ErrPasskeysNotConfigured
+	}
+
+	clientIP := core.ClientInfoFromContext(ctx).IP
+
+	err := s.lockout.CheckIP(ctx, clientIP)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	session, err := s.passkeyStorage.UsePasskeySession(ctx, sessionID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	if session.Purpose != core.PasskeyLogin && session.Purpose != core.PasskeyMFA {
+		return nil, core.ErrInvalidPasskeySession
+	}
+
+	if session.UserID != 0 {
+		err = s.lockout.CheckUser(ctx, session.UserID)
+		if err != nil {
+			logger.Log().Error(ctx, err.Error())
+			return nil, err
+		}
+	}
+
+	credential, err := s.relyingParty.FinishLogin(session.Data, response, func(userID int) (*core.PasskeyUser, error) {
+		return s.passkeyUser(ctx, userID)
+	})
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		s.loginFailed(ctx, session.UserID, "", clientIP)
+		return nil, core.ErrInvalidPasskeyResponse
+	}
+
+	// Owner of discoverable credential is known only now
+	if session.UserID == 0 {
+		err = s.lockout.CheckUser(ctx, credential.UserID)
+		if err != nil {
+			logger.Log().Error(ctx, err.Error())
+			return nil, err
+		}
+	}
+
+	err = s.passkeyStorage.UpdatePasskeyCredential(ctx, *credential)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	err = s.lockout.Succeed(ctx, credential.UserID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+	}
+
+	return s.startSession(ctx, credential.UserID)
+}
+
+func (s *service) startPasskeySession(ctx context.Context, userID int, purpose string, options []byte, data []byte) (*core.PasskeyChallenge, error) {
+	sessionID, err := opaque.NewID()
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	err = s.passkeyStorage.AddPasskeySession(ctx, core.PasskeySession{
+		ID:        sessionID,
+		UserID:    userID,
+		Purpose:   purpose,
+		Data:      data,
+		ExpiresAt: time.Now().Add(PasskeySessionTTL),
+	})
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return &core.PasskeyChallenge{
+		SessionID: sessionID,
+		Options:   options,
+	}, nil
+}
+
+func (s *service) passkeyUser(ctx context.Context, userID int) (*core.PasskeyUser, error) {
+	user, err := s.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := s.passkeyStorage.GetPasskeyCredentials(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &core.PasskeyUser{
+		ID:          user.ID,
+		Name:        user.Username,
+		Credentials: credentials,
+	}, nil
+}
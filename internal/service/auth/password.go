@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+)
+
+// hashPassword peppers password with current pepper and hashes it,
+// pepper version is returned to be stored with the hash
+func (s *service) hashPassword(password string) (hash string, pepperVersion int, err error) {
+	pepperVersion = s.pepper.CurrentVersion()
+
+	peppered, err := s.pepper.Apply(pepperVersion, password)
+	if err != nil {
+		return "", 0, err
+	}
+
+	hash, err = s.hasher.Hash(peppered)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return hash, pepperVersion, nil
+}
+
+func (s *service) verifyPassword(user core.User, password string) error {
+	peppered, err := s.pepper.Apply(user.PepperVersion, password)
+	if err != nil {
+		return err
+	}
+
+	return s.hasher.Verify(peppered, user.PasswordHash)
+}
+
+// needsRehash reports whether hash of the user uses outdated hasher settings or pepper
+func (s *service) needsRehash(user core.User) bool {
+	return user.PepperVersion != s.pepper.CurrentVersion() || s.hasher.NeedsRehash(user.PasswordHash)
+}
+
+func (s *service) setPassword(user *core.User, password string) error {
+	hash, pepperVersion, err := s.hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	user.PasswordHash = hash
+	user.PepperVersion = pepperVersion
+
+	return nil
+}
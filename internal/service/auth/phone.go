@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/logger"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/opaque"
+)
+
+// e164 matches phone number in international format without separators
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// SetPhone stores the phone unverified, codes are not sent to it by SMS
+// until the user enters the code sent with this call
+func (s *service) SetPhone(ctx context.Context, userID int, password string, phone string) (err error) {
+	defer func() { s.audit(ctx, core.AuditPhoneChanged, userID, userID, "", err) }()
+
+	if s.smsNotifier == nil {
+		return core.ErrSMSNotConfigured
+	}
+
+	phone, err = normalizePhone("phone", phone)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	err = s.verifyPassword(*user, password)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return core.ErrInvalidCredentials
+	}
+
+	err = s.userStorage.SetPhone(ctx, userID, phone)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	code, err := newOTPCode(s.otpConfig.Length)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	err = s.phoneStorage.AddPhoneVerification(ctx, core.PhoneVerification{
+		UserID:    userID,
+		Phone:     phone,
+		CodeHash:  phoneCodeHash(userID, phone, code),
+		ExpiresAt: time.Now().Add(s.otpConfig.TTL),
+	})
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	err = s.smsNotifier.Notify(ctx, core.Notification{
+		UserID:   userID,
+		Username: user.Username,
+		To:       phone,
+		Subject:  "Phone verification",
+		Body: fmt.Sprintf("Your verification code is %s, it expires in %d minutes",
+			code, int(s.otpConfig.TTL.Minutes())),
+	})
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// VerifyPhone checks code sent to the current phone of the user,
+// codes sent to its previous phones do not match
+func (s *service) VerifyPhone(ctx context.Context, userID int, code string) error {
+	user, err := s.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	if user.Phone == "" {
+		return core.ErrInvalidOTP
+	}
+
+	phone, err := s.phoneStorage.UsePhoneVerification(ctx, userID, phoneCodeHash(userID, user.Phone, code), s.otpConfig.MaxAttempts)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	err = s.userStorage.SetPhoneVerified(ctx, userID, phone)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// otpNotifier returns notifier of the channel, email is used if channel is empty
+func (s *service) otpNotifier(channel string) (core.Notifier, error) {
+	switch channel {
+	case "", core.OTPChannelEmail:
+		return s.notifier, nil
+	case core.OTPChannelSMS:
+		if s.smsNotifier == nil {
+			return nil, core.ErrSMSNotConfigured
+		}
+		return s.smsNotifier, nil
+	}
+
+	return nil, &core.ValidationError{Violations: []core.FieldViolation{{
+		Field:       "channel",
+		Description: "must be email or sms",
+	}}}
+}
+
+// otpAddress returns address of the user on the channel,
+// it is empty until the address is verified
+func otpAddress(user core.User, channel string) string {
+	if channel == core.OTPChannelSMS {
+		if !user.PhoneVerified {
+			return ""
+		}
+		return user.Phone
+	}
+
+	return notificationAddress(user)
+}
+
+// phoneCodeHash binds code to the user and the phone it was sent to
+func phoneCodeHash(userID int, phone string, code string) string {
+	return opaque.Hash(strconv.Itoa(userID) + ":" + phone + ":" + code)
+}
+
+// normalizePhone removes separators and returns the phone in E.164 format,
+// field is the name of request field that is reported in violations
+func normalizePhone(field string, phone string) (string, error) {
+	phone = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, phone)
+
+	if !e164.MatchString(phone) {
+		return "", &core.ValidationError{Violations: []core.FieldViolation{{
+			Field:       field,
+			Description: "must be a phone number in international format, like +14155550123",
+		}}}
+	}
+
+	return phone, nil
+}
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+)
+
+// bcrypt ignores everything after 72 bytes
+const (
+	bcryptScheme   = "bcrypt"
+	bcryptMaxBytes = 72
+)
+
+// minUsernameLength is the length from which username is searched in password
+const minUsernameLength = 3
+
+// Top common passwords, they are rejected regardless of other rules
+//
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+type PasswordPolicy struct {
+	config          core.PasswordPolicyConfig
+	commonPasswords map[string]struct{}
+	breached        core.BreachedPasswordChecker
+}
+
+// NewPasswordPolicy creates policy, breached may be nil to skip breach check
+func NewPasswordPolicy(config core.PasswordPolicyConfig, breached core.BreachedPasswordChecker) *PasswordPolicy {
+	commonPasswords := make(map[string]struct{})
+
+	scanner := bufio.NewScanner(strings.NewReader(commonPasswordsFile))
+	for scanner.Scan() {
+		if password := strings.TrimSpace(scanner.Text()); password != "" {
+			commonPasswords[strings.ToLower(password)] = struct{}{}
+		}
+	}
+
+	return &PasswordPolicy{
+		config:          config,
+		commonPasswords: commonPasswords,
+		breached:        breached,
+	}
+}
+
+// Validate checks password of the user, field is the name of request field
+// that is reported in violations
+func (p *PasswordPolicy) Validate(ctx context.Context, field string, username string, password string) error {
+	var violations []core.FieldViolation
+	violate := func(format string, args ...any) {
+		violations = append(violations, core.FieldViolation{
+			Field:       field,
+			Description: fmt.Sprintf(format, args...),
+		})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.config.MinLength {
+		violate("must be at least %d characters long", p.config.MinLength)
+	}
+
+	if p.config.MaxLength > 0 && length > p.config.MaxLength {
+		violate("must be at most %d characters long", p.config.MaxLength)
+	}
+
+	if p.config.HashScheme == bcryptScheme && !p.config.Peppered && len(password) > bcryptMaxBytes {
+		violate("must be at most %d bytes long", bcryptMaxBytes)
+	}
+
+	if classes := characterClasses(password); classes < p.config.MinClasses {
+		violate("must contain at least %d of: lowercase letters, uppercase letters, digits, symbols", p.config.MinClasses)
+	}
+
+	lowerPassword := strings.ToLower(password)
+
+	if utf8.RuneCountInString(username) >= minUsernameLength && strings.Contains(lowerPassword, strings.ToLower(username)) {
+		violate("must not contain username")
+	}
+
+	if _, ok := p.commonPasswords[lowerPassword]; ok {
+		violate("is too common")
+	} else if p.breached != nil && password != "" {
+		count, err := p.breached.BreachCount(ctx, password)
+		if err != nil {
+			return err
+		}
+
+		if count >= max(p.config.BreachThreshold, 1) {
+			violate("has appeared in a data breach")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &core.ValidationError{Violations: violations}
+	}
+
+	return nil
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+
+	return lower + upper + digit + symbol
+}
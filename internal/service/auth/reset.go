@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/logger"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/opaque"
+)
+
+// RequestPasswordReset sends reset token to the user, unknown usernames
+// are not reported, so the method can not be used to enumerate accounts
+func (s *service) RequestPasswordReset(ctx context.Context, username string) error {
+	user, err := s.findUser(ctx, username)
+	if err != nil {
+		if errors.Is(err, core.ErrInvalidCredentials) {
+			logger.Log().Debug(ctx, "password reset for unknown user")
+			return nil
+		}
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	err = s.sendPasswordReset(ctx, *user)
+	if err != nil {
+		// Users without address get the same response as unknown ones
+		if errors.Is(err, core.ErrNoRecipient) {
+			logger.Log().Debug(ctx, "password reset for user without address")
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// sendPasswordReset issues reset token and sends it to the user
+func (s *service) sendPasswordReset(ctx context.Context, user core.User) error {
+	token, err := opaque.NewToken()
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	err = s.resetStorage.AddPasswordReset(ctx, core.PasswordReset{
+		UserID:    user.ID,
+		TokenHash: opaque.Hash(token),
+		ExpiresAt: time.Now().Add(time.Minute * time.Duration(s.authConfig.PasswordResetTTL)),
+	})
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	err = s.notifier.Notify(ctx, core.Notification{
+		UserID:   user.ID,
+		Username: user.Username,
+		To:       notificationAddress(user),
+		Subject:  "Password reset",
+		Body:     s.passwordResetBody(token),
+	})
+	if err != nil {
+		if !errors.Is(err, core.ErrNoRecipient) {
+			logger.Log().Error(ctx, err.Error())
+		}
+		return err
+	}
+
+	return nil
+}
+
+// ConfirmPasswordReset consumes reset token and sets new password at once,
+// all sessions of the user are revoked afterwards
+func (s *service) ConfirmPasswordReset(ctx context.Context, token string, newPassword string) error {
+	tokenHash := opaque.Hash(token)
+
+	// Token is consumed only after password is accepted by the policy
+	userID, err := s.resetStorage.GetPasswordReset(ctx, tokenHash)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	user, err := s.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	err = s.policy.Validate(ctx, "newPassword", user.Username, newPassword)
+	if err != nil {
+		return err
+	}
+
+	err = s.setPassword(user, newPassword)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	// Token is consumed only if password is changed, a failed update leaves it usable
+	err = s.resetStorage.UsePasswordReset(ctx, tokenHash, *user)
+	s.audit(ctx, core.AuditPasswordReset, userID, userID, "", err)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	return s.revokeAllSessions(ctx, userID)
+}
+
+func (s *service) passwordResetBody(token string) string {
+	if s.authConfig.PasswordResetURL == "" {
+		return fmt.Sprintf("Use this code to reset your password: %s", token)
+	}
+
+	return fmt.Sprintf("Follow the link to reset your password: %s?token=%s",
+		s.authConfig.PasswordResetURL, url.QueryEscape(token))
+}
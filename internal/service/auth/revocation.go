@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/cache"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/logger"
+)
+
+type revocation struct {
+	revocationStorage core.RevocationStore
+	sessionStorage    core.SessionStore
+
+	// Caches keep every authenticated call from hitting database,
+	// revocations made by other replicas are seen after cache ttl
+	revoked     *cache.Cache[string, bool]
+	generations *cache.Cache[int, int]
+	sessions    *cache.Cache[string, bool]
+}
+
+func NewRevocation(revocationStorage core.RevocationStore, sessionStorage core.SessionStore, cacheTTL time.Duration) core.RevocationService {
+	return &revocation{
+		revocationStorage: revocationStorage,
+		sessionStorage:    sessionStorage,
+		revoked:           cache.New[string, bool](cacheTTL),
+		generations:       cache.New[int, int](cacheTTL),
+		sessions:          cache.New[string, bool](cacheTTL),
+	}
+}
+
+func (r *revocation) RevokeToken(ctx context.Context, claims core.Claims) error {
+	err := r.revocationStorage.RevokeToken(ctx, claims.ID, claims.UserID, claims.ExpiresAt)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	r.revoked.Set(claims.ID, true)
+
+	return nil
+}
+
+func (r *revocation) RevokeAllTokens(ctx context.Context, userID int) error {
+	generation, err := r.revocationStorage.IncrementTokenGeneration(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	r.generations.Set(userID, generation)
+
+	return nil
+}
+
+func (r *revocation) RevokeSession(ctx context.Context, userID int, sessionID string) error {
+	err := r.sessionStorage.RevokeSession(ctx, userID, sessionID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	r.sessions.Set(sessionID, true)
+
+	return nil
+}
+
+func (r *revocation) IsRevoked(ctx context.Context, claims core.Claims) (bool, error) {
+	generation, ok := r.generations.Get(claims.UserID)
+	if !ok {
+		var err error
+		generation, err = r.revocationStorage.GetTokenGeneration(ctx, claims.UserID)
+		if err != nil {
+			// Tokens of deleted users are revoked
+			if errors.Is(err, core.ErrUnauthorized) {
+				return true, nil
+			}
+			logger.Log().Error(ctx, err.Error())
+			return false, err
+		}
+
+		r.generations.Set(claims.UserID, generation)
+	}
+
+	if claims.Generation < generation {
+		return true, nil
+	}
+
+	// Only access tokens belong to a session
+	if claims.SessionID != "" {
+		revoked, ok := r.sessions.Get(claims.SessionID)
+		if !ok {
+			var err error
+			revoked, err = r.sessionStorage.IsSessionRevoked(ctx, claims.SessionID)
+			if err != nil {
+				logger.Log().Error(ctx, err.Error())
+				return false, err
+			}
+
+			r.sessions.Set(claims.SessionID, revoked)
+		}
+
+		if revoked {
+			return true, nil
+		}
+	}
+
+	revoked, ok := r.revoked.Get(claims.ID)
+	if !ok {
+		var err error
+		revoked, err = r.revocationStorage.IsTokenRevoked(ctx, claims.ID)
+		if err != nil {
+			logger.Log().Error(ctx, err.Error())
+			return false, err
+		}
+
+		r.revoked.Set(claims.ID, revoked)
+	}
+
+	return revoked, nil
+}
+
+// TokenGeneration is used for issuing new tokens, so it always reads from database
+func (r *revocation) TokenGeneration(ctx context.Context, userID int) (int, error) {
+	generation, err := r.revocationStorage.GetTokenGeneration(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return 0, err
+	}
+
+	r.generations.Set(userID, generation)
+
+	return generation, nil
+}
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/logger"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/opaque"
+)
+
+const (
+	maxDeviceNameLength = 64
+	maxUserAgentLength  = 255
+)
+
+// ListSessions returns sessions of the user that can still be refreshed
+func (s *service) ListSessions(ctx context.Context, userID int) ([]core.Session, error) {
+	activeSince := time.Now().Add(-time.Hour * time.Duration(s.authConfig.RefreshTokenTTL))
+
+	sessions, err := s.sessionStorage.GetSessions(ctx, userID, activeSince)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession logs out the session, its access tokens
+// are rejected and its refresh token can not be used
+func (s *service) RevokeSession(ctx context.Context, userID int, sessionID string) (err error) {
+	defer func() { s.audit(ctx, core.AuditSessionRevoked, userID, userID, sessionID, err) }()
+
+	err = s.revocation.RevokeSession(ctx, userID, sessionID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	err = s.tokenStorage.RevokeRefreshTokenFamily(ctx, sessionID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// startSession is called on every login, the session is a new refresh token family
+func (s *service) startSession(ctx context.Context, userID int) (tokens *core.TokenPair, err error) {
+	defer func() { s.audit(ctx, core.AuditLogin, userID, userID, "", err) }()
+
+	user, err := s.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	// Sessions of disabled users are logged out, so they can not refresh either
+	if user.Disabled {
+		return nil, core.ErrAccountDisabled
+	}
+
+	sessionID, err := opaque.NewID()
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	client := sessionClientInfo(ctx)
+
+	err = s.sessionStorage.AddSession(ctx, core.Session{
+		ID:         sessionID,
+		UserID:     userID,
+		DeviceName: client.DeviceName,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+	})
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return s.issueTokens(ctx, userID, sessionID)
+}
+
+// touchSession records refresh of the session, refresh is not failed if it does not succeed
+func (s *service) touchSession(ctx context.Context, sessionID string) {
+	err := s.sessionStorage.TouchSession(ctx, sessionID, sessionClientInfo(ctx))
+	if err != nil {
+		logger.Log().Error(ctx, err.Error())
+	}
+}
+
+// endSession marks the session revoked, sessions started before
+// sessions were stored are not found and it is fine
+func (s *service) endSession(ctx context.Context, userID int, sessionID string) error {
+	err := s.revocation.RevokeSession(ctx, userID, sessionID)
+	if err != nil && !errors.Is(err, core.ErrSessionNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+// sessionClientInfo returns client info cut to the size of session columns
+func sessionClientInfo(ctx context.Context) core.ClientInfo {
+	client := core.ClientInfoFromContext(ctx)
+	client.DeviceName = truncate(client.DeviceName, maxDeviceNameLength)
+	client.UserAgent = truncate(client.UserAgent, maxUserAgentLength)
+
+	return client
+}
+
+func truncate(s string, length int) string {
+	if utf8.RuneCountInString(s) <= length {
+		return s
+	}
+
+	return string([]rune(s)[:length])
+}
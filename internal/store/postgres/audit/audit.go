@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/postgres"
+)
+
+type store struct {
+	*postgres.Postgres
+}
+
+func New(pg *postgres.Postgres) core.AuditStore {
+	return &store{pg}
+}
+
+func (s *store) AddAuditEvent(ctx context.Context, event core.AuditEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	// Users are not referenced, so events outlive deleted users
+	stmt := `INSERT INTO audit_events (type, actor_id, target_id, ip, user_agent, request_id, outcome, details)
+	VALUES ($1, NULLIF($2, 0), NULLIF($3, 0), $4, $5, $6, $7, $8)`
+
+	_, err := s.DB.ExecContext(ctx, stmt, event.Type, event.ActorID, event.TargetID, event.IP, event.UserAgent,
+		event.RequestID, event.Outcome, event.Details)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *store) GetAuditEvents(ctx context.Context, filter core.AuditFilter, beforeID int64, limit int) ([]core.AuditEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var since, until *time.Time
+	if !filter.Since.IsZero() {
+		since = &filter.Since
+	}
+	if !filter.Until.IsZero() {
+		until = &filter.Until
+	}
+
+	stmt := `SELECT id, type, COALESCE(actor_id, 0), COALESCE(target_id, 0), ip, user_agent, request_id, outcome, details, created_at
+	FROM audit_events
+	WHERE ($1::BIGINT = 0 OR id < $1)
+	AND ($2::INTEGER = 0 OR actor_id = $2 OR target_id = $2)
+	AND ($3::TEXT = '' OR type = $3)
+	AND ($4::TIMESTAMPTZ IS NULL OR created_at >= $4)
+	AND ($5::TIMESTAMPTZ IS NULL OR created_at < $5)
+	ORDER BY id DESC LIMIT $6`
+
+	rows, err := s.DB.QueryContext(ctx, stmt, beforeID, filter.UserID, filter.Type, since, until, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []core.AuditEvent
+	for rows.Next() {
+		var event core.AuditEvent
+
+		err = rows.Scan(&event.ID, &event.Type, &event.ActorID, &event.TargetID, &event.IP, &event.UserAgent,
+			&event.RequestID, &event.Outcome, &event.Details, &event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (s *store) DeleteAuditEvents(ctx context.Context, before time.Time, limit int) (deleted int64, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	// Events are deleted in batches, so one call does not hold locks for long
+	stmt := `DELETE FROM audit_events WHERE id IN (
+		SELECT id FROM audit_events WHERE created_at < $1 ORDER BY id LIMIT $2
+	)`
+
+	result, err := s.DB.ExecContext(ctx, stmt, before, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
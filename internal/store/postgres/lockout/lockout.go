@@ -0,0 +1,96 @@
+package lockout
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/postgres"
+)
+
+type store struct {
+	*postgres.Postgres
+}
+
+func New(pg *postgres.Postgres) core.LockoutStore {
+	return &store{pg}
+}
+
+func (s *store) GetLoginFailures(ctx context.Context, scope string, subject string) (*core.LoginFailures, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	failures := new(core.LoginFailures)
+
+	stmt := `SELECT failures, last_failed_at, locked_until FROM login_failures
+	WHERE scope = $1 AND subject = $2`
+
+	err := s.DB.QueryRowContext(ctx, stmt, scope, subject).Scan(&failures.Failures, &failures.LastFailedAt, &failures.LockedUntil)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return failures, nil
+		}
+		return nil, err
+	}
+
+	return failures, nil
+}
+
+// AddLoginFailure increments failures, counting starts over
+// if the previous failure is older than window
+func (s *store) AddLoginFailure(ctx context.Context, scope string, subject string, window time.Duration) (*core.LoginFailures, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	failures := new(core.LoginFailures)
+
+	stmt := `INSERT INTO login_failures (scope, subject, failures, last_failed_at)
+	VALUES ($1, $2, 1, NOW())
+	ON CONFLICT (scope, subject) DO UPDATE SET
+		failures = CASE
+			WHEN login_failures.last_failed_at < NOW() - make_interval(secs => $3) THEN 1
+			ELSE login_failures.failures + 1
+		END,
+		last_failed_at = NOW()
+	RETURNING failures, last_failed_at, locked_until`
+
+	err := s.DB.QueryRowContext(ctx, stmt, scope, subject, window.Seconds()).Scan(&failures.Failures, &failures.LastFailedAt, &failures.LockedUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	return failures, nil
+}
+
+// LockLogin locks logins until the time, failures are reset,
+// so counting starts over when the lock expires
+func (s *store) LockLogin(ctx context.Context, scope string, subject string, until time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `UPDATE login_failures SET locked_until = $3, failures = 0
+	WHERE scope = $1 AND subject = $2`
+
+	_, err := s.DB.ExecContext(ctx, stmt, scope, subject, until)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *store) ClearLoginFailures(ctx context.Context, scope string, subject string) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `DELETE FROM login_failures WHERE scope = $1 AND subject = $2`
+
+	_, err := s.DB.ExecContext(ctx, stmt, scope, subject)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package mfa
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/postgres"
+)
+
+type store struct {
+	*postgres.Postgres
+}
+
+func New(pg *postgres.Postgres) core.MFAStore {
+	return &store{pg}
+}
+
+func (s *store) SetTOTP(ctx context.Context, totp core.TOTP) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `INSERT INTO user_totp (user_id, secret) VALUES ($1, $2)
+	ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, created_at = NOW()
+	WHERE user_totp.confirmed_at IS NULL`
+
+	res, err := s.DB.ExecContext(ctx, stmt, totp.UserID, totp.Secret)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return core.ErrMFAAlreadyEnabled
+	}
+
+	return nil
+}
+
+func (s *store) GetTOTP(ctx context.Context, userID int) (*core.TOTP, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	totp := new(core.TOTP)
+
+	stmt := `SELECT user_id, secret, confirmed_at, last_used_step FROM user_totp WHERE user_id = $1`
+	err := s.DB.QueryRowContext(ctx, stmt, userID).Scan(&totp.UserID, &totp.Secret, &totp.ConfirmedAt, &totp.LastUsedStep)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, core.ErrMFANotEnrolled
+		}
+		return nil, err
+	}
+
+	return totp, nil
+}
+
+func (s *store) ConfirmTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	// starting transaction
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	stmt := `UPDATE user_totp SET confirmed_at = NOW(), last_used_step = $2
+	WHERE user_id = $1 AND confirmed_at IS NULL`
+
+	res, err := tx.ExecContext(ctx, stmt, userID, step)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return core.ErrMFAAlreadyEnabled
+	}
+
+	stmt = `DELETE FROM recovery_codes WHERE user_id = $1`
+
+	_, err = tx.ExecContext(ctx, stmt, userID)
+	if err != nil {
+		return err
+	}
+
+	stmt = `INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)`
+
+	for _, codeHash := range recoveryCodeHashes {
+		_, err = tx.ExecContext(ctx, stmt, userID, codeHash)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *store) UseTOTPStep(ctx context.Context, userID int, step int64) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	// Conditional update, so the same code is accepted only once
+	stmt := `UPDATE user_totp SET last_used_step = $2
+	WHERE user_id = $1 AND confirmed_at IS NOT NULL AND last_used_step < $2`
+
+	res, err := s.DB.ExecContext(ctx, stmt, userID, step)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return core.ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+func (s *store) UseRecoveryCode(ctx context.Context, userID int, codeHash string) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `UPDATE recovery_codes SET used_at = NOW()
+	WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	res, err := s.DB.ExecContext(ctx, stmt, userID, codeHash)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return core.ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+func (s *store) DeleteTOTP(ctx context.Context, userID int) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	// starting transaction
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	stmt := `DELETE FROM recovery_codes WHERE user_id = $1`
+
+	_, err = tx.ExecContext(ctx, stmt, userID)
+	if err != nil {
+		return err
+	}
+
+	stmt = `DELETE FROM user_totp WHERE user_id = $1`
+
+	_, err = tx.ExecContext(ctx, stmt, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package otp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/postgres"
+)
+
+type store struct {
+	*postgres.Postgres
+}
+
+func New(pg *postgres.Postgres) core.OTPStore {
+	return &store{pg}
+}
+
+// AddOTPLogin stores new code, previous and expired codes of the user are deleted
+func (s *store) AddOTPLogin(ctx context.Context, login core.OTPLogin) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	// starting transaction
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	stmt := `DELETE FROM otp_logins WHERE user_id = $1 OR expires_at < NOW()`
+
+	_, err = tx.ExecContext(ctx, stmt, login.UserID)
+	if err != nil {
+		return err
+	}
+
+	stmt = `INSERT INTO otp_logins (id, user_id, code_hash, expires_at)
+	VALUES ($1, $2, $3, $4)`
+
+	_, err = tx.ExecContext(ctx, stmt, login.ID, login.UserID, login.CodeHash, login.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UseOTPLogin counts the attempt in the same statement that checks the code,
+// so parallel requests can not make more attempts than allowed. Owner of
+// the login is returned with the error if the code does not match
+func (s *store) UseOTPLogin(ctx context.Context, loginID string, codeHash string, maxAttempts int) (userID int, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `UPDATE otp_logins SET attempts = attempts + 1,
+		used_at = CASE WHEN code_hash = $3 THEN NOW() END
+	WHERE id = $1 AND used_at IS NULL AND expires_at > NOW() AND attempts < $2
+	RETURNING user_id, used_at IS NOT NULL`
+
+	var used bool
+	err = s.DB.QueryRowContext(ctx, stmt, loginID, maxAttempts, codeHash).Scan(&userID, &used)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, core.ErrInvalidOTP
+		}
+		return 0, err
+	}
+
+	if !used {
+		return userID, core.ErrInvalidOTP
+	}
+
+	return userID, nil
+}
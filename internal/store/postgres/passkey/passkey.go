@@ -0,0 +1,162 @@
+package passkey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/postgres"
+)
+
+type store struct {
+	*postgres.Postgres
+}
+
+func New(pg *postgres.Postgres) core.PasskeyStore {
+	return &store{pg}
+}
+
+func (s *store) AddPasskeyCredential(ctx context.Context, credential core.PasskeyCredential) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `INSERT INTO passkey_credentials (user_id, credential_id, public_key, attestation_type,
+		aaguid, sign_count, transports, backup_eligible, backup_state, name)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	_, err := s.DB.ExecContext(ctx, stmt,
+		credential.UserID,
+		credential.CredentialID,
+		credential.PublicKey,
+		credential.AttestationType,
+		credential.AAGUID,
+		credential.SignCount,
+		strings.Join(credential.Transports, ","),
+		credential.BackupEligible,
+		credential.BackupState,
+		credential.Name,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *store) GetPasskeyCredentials(ctx context.Context, userID int) ([]core.PasskeyCredential, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `SELECT id, user_id, credential_id, public_key, attestation_type, aaguid, sign_count,
+		transports, backup_eligible, backup_state, name, created_at, last_used_at
+	FROM passkey_credentials WHERE user_id = $1 ORDER BY id`
+
+	rows, err := s.DB.QueryContext(ctx, stmt, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var credentials []core.PasskeyCredential
+	for rows.Next() {
+		var (
+			credential core.PasskeyCredential
+			transports string
+		)
+
+		err = rows.Scan(
+			&credential.ID,
+			&credential.UserID,
+			&credential.CredentialID,
+			&credential.PublicKey,
+			&credential.AttestationType,
+			&credential.AAGUID,
+			&credential.SignCount,
+			&transports,
+			&credential.BackupEligible,
+			&credential.BackupState,
+			&credential.Name,
+			&credential.CreatedAt,
+			&credential.LastUsedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if transports != "" {
+			credential.Transports = strings.Split(transports, ",")
+		}
+
+		credentials = append(credentials, credential)
+	}
+
+	return credentials, rows.Err()
+}
+
+func (s *store) UpdatePasskeyCredential(ctx context.Context, credential core.PasskeyCredential) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `UPDATE passkey_credentials SET sign_count = $2, backup_state = $3, last_used_at = NOW()
+	WHERE credential_id = $1`
+
+	_, err := s.DB.ExecContext(ctx, stmt, credential.CredentialID, credential.SignCount, credential.BackupState)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *store) AddPasskeySession(ctx context.Context, session core.PasskeySession) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	userID := sql.NullInt64{Int64: int64(session.UserID), Valid: session.UserID != 0}
+
+	stmt := `INSERT INTO passkey_sessions (id, user_id, purpose, data, expires_at)
+	VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := s.DB.ExecContext(ctx, stmt, session.ID, userID, session.Purpose, session.Data, session.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	// Abandoned ceremonies are not finished, so they are removed here
+	stmt = `DELETE FROM passkey_sessions WHERE expires_at < NOW()`
+
+	_, err = s.DB.ExecContext(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *store) UsePasskeySession(ctx context.Context, sessionID string) (*core.PasskeySession, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var (
+		session = core.PasskeySession{ID: sessionID}
+		userID  sql.NullInt64
+	)
+
+	stmt := `DELETE FROM passkey_sessions WHERE id = $1 AND expires_at > NOW()
+	RETURNING user_id, purpose, data, expires_at`
+
+	err := s.DB.QueryRowContext(ctx, stmt, sessionID).Scan(&userID, &session.Purpose, &session.Data, &session.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, core.ErrInvalidPasskeySession
+		}
+		return nil, err
+	}
+
+	session.UserID = int(userID.Int64)
+
+	return &session, nil
+}
@@ -0,0 +1,65 @@
+package phone
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/postgres"
+)
+
+type store struct {
+	*postgres.Postgres
+}
+
+func New(pg *postgres.Postgres) core.PhoneVerificationStore {
+	return &store{pg}
+}
+
+// AddPhoneVerification replaces code of the user, so only the last sent code works
+func (s *store) AddPhoneVerification(ctx context.Context, verification core.PhoneVerification) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `INSERT INTO phone_verifications (user_id, phone, code_hash, expires_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (user_id) DO UPDATE SET phone = EXCLUDED.phone, code_hash = EXCLUDED.code_hash,
+		attempts = 0, expires_at = EXCLUDED.expires_at, created_at = NOW()`
+
+	_, err := s.DB.ExecContext(ctx, stmt, verification.UserID, verification.Phone, verification.CodeHash,
+		verification.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UsePhoneVerification counts the attempt in the same statement that checks the code,
+// so parallel requests can not make more attempts than allowed. Matched code uses
+// up all attempts, so it can not be used again
+func (s *store) UsePhoneVerification(ctx context.Context, userID int, codeHash string, maxAttempts int) (phone string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `UPDATE phone_verifications SET attempts = CASE WHEN code_hash = $3 THEN $2 ELSE attempts + 1 END
+	WHERE user_id = $1 AND expires_at > NOW() AND attempts < $2
+	RETURNING phone, code_hash = $3`
+
+	var matched bool
+	err = s.DB.QueryRowContext(ctx, stmt, userID, maxAttempts, codeHash).Scan(&phone, &matched)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", core.ErrInvalidOTP
+		}
+		return "", err
+	}
+
+	if !matched {
+		return "", core.ErrInvalidOTP
+	}
+
+	return phone, nil
+}
@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/postgres"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/ratelimit"
+)
+
+// sweepEvery is the number of calls after which idle buckets are removed
+const sweepEvery = 1024
+
+type store struct {
+	*postgres.Postgres
+	calls atomic.Int64
+}
+
+// New keeps buckets in postgres, so limits are shared between replicas
+func New(pg *postgres.Postgres) core.RateLimiter {
+	return &store{Postgres: pg}
+}
+
+func (s *store) Allow(ctx context.Context, key string, limit core.RateLimit) (allowed bool, retryAfter time.Duration, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if s.calls.Add(1)%sweepEvery == 0 {
+		err = s.sweep(ctx)
+		if err != nil {
+			return false, 0, err
+		}
+	}
+
+	// starting transaction
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, 0, err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	stmt := `INSERT INTO rate_limit_buckets (key, tokens, updated_at, expires_at)
+	VALUES ($1, $2, NOW(), NOW()) ON CONFLICT (key) DO NOTHING`
+
+	_, err = tx.ExecContext(ctx, stmt, key, limit.Requests)
+	if err != nil {
+		return false, 0, err
+	}
+
+	// Database clock is used, so replicas agree on time
+	stmt = `SELECT tokens, updated_at, NOW() FROM rate_limit_buckets
+	WHERE key = $1 FOR UPDATE`
+
+	var (
+		bucket ratelimit.Bucket
+		now    time.Time
+	)
+
+	err = tx.QueryRowContext(ctx, stmt, key).Scan(&bucket.Tokens, &bucket.UpdatedAt, &now)
+	if err != nil {
+		return false, 0, err
+	}
+
+	allowed, retryAfter = bucket.Take(limit, now)
+
+	stmt = `UPDATE rate_limit_buckets SET tokens = $2, updated_at = $3, expires_at = $4
+	WHERE key = $1`
+
+	_, err = tx.ExecContext(ctx, stmt, key, bucket.Tokens, bucket.UpdatedAt, now.Add(limit.Period))
+	if err != nil {
+		return false, 0, err
+	}
+
+	return allowed, retryAfter, nil
+}
+
+// sweep removes buckets that are full again
+func (s *store) sweep(ctx context.Context) error {
+	stmt := `DELETE FROM rate_limit_buckets WHERE expires_at < NOW()`
+
+	_, err := s.DB.ExecContext(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
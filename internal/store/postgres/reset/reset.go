@@ -0,0 +1,129 @@
+package reset
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/postgres"
+)
+
+type store struct {
+	*postgres.Postgres
+}
+
+func New(pg *postgres.Postgres) core.PasswordResetStore {
+	return &store{pg}
+}
+
+// AddPasswordReset stores new reset token, previous tokens of the user stop working
+func (s *store) AddPasswordReset(ctx context.Context, reset core.PasswordReset) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	// starting transaction
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	stmt := `UPDATE password_resets SET used_at = NOW()
+	WHERE user_id = $1 AND used_at IS NULL`
+
+	_, err = tx.ExecContext(ctx, stmt, reset.UserID)
+	if err != nil {
+		return err
+	}
+
+	stmt = `INSERT INTO password_resets (user_id, token_hash, expires_at)
+	VALUES ($1, $2, $3)`
+
+	_, err = tx.ExecContext(ctx, stmt, reset.UserID, reset.TokenHash, reset.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetPasswordReset returns owner of the token if it can still be used
+func (s *store) GetPasswordReset(ctx context.Context, tokenHash string) (userID int, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `SELECT user_id FROM password_resets
+	WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()`
+
+	err = s.DB.QueryRowContext(ctx, stmt, tokenHash).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, core.ErrInvalidResetToken
+		}
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+// UsePasswordReset consumes token and sets password of the user in one transaction,
+// token works only once and only before expiration
+func (s *store) UsePasswordReset(ctx context.Context, tokenHash string, user core.User) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	// starting transaction
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	stmt := `UPDATE password_resets SET used_at = NOW()
+	WHERE token_hash = $1 AND user_id = $2 AND used_at IS NULL AND expires_at > NOW()
+	RETURNING user_id`
+
+	var userID int
+	err = tx.QueryRowContext(ctx, stmt, tokenHash, user.ID).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.ErrInvalidResetToken
+		}
+		return err
+	}
+
+	stmt = `UPDATE users SET password_hash = $1, pepper_version = $2, password_reset_required = FALSE
+	WHERE id = $3`
+
+	result, err := tx.ExecContext(ctx, stmt, user.PasswordHash, user.PepperVersion, user.ID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return core.ErrUserNotFound
+	}
+
+	return nil
+}
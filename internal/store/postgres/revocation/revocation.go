@@ -0,0 +1,87 @@
+package revocation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/postgres"
+)
+
+type store struct {
+	*postgres.Postgres
+}
+
+func New(pg *postgres.Postgres) core.RevocationStore {
+	return &store{pg}
+}
+
+func (s *store) RevokeToken(ctx context.Context, tokenID string, userID int, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `INSERT INTO revoked_tokens (jti, user_id, expires_at)
+	VALUES ($1, $2, $3) ON CONFLICT (jti) DO NOTHING`
+
+	_, err := s.DB.ExecContext(ctx, stmt, tokenID, userID, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	// Expired tokens are rejected anyway, there is no need to keep them
+	stmt = `DELETE FROM revoked_tokens WHERE expires_at < NOW()`
+
+	_, err = s.DB.ExecContext(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *store) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)`
+
+	var revoked bool
+	err := s.DB.QueryRowContext(ctx, stmt, tokenID).Scan(&revoked)
+	if err != nil {
+		return false, err
+	}
+
+	return revoked, nil
+}
+
+func (s *store) GetTokenGeneration(ctx context.Context, userID int) (generation int, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `SELECT token_generation FROM users WHERE id = $1`
+	err = s.DB.QueryRowContext(ctx, stmt, userID).Scan(&generation)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, core.ErrUnauthorized
+		}
+		return 0, err
+	}
+
+	return generation, nil
+}
+
+func (s *store) IncrementTokenGeneration(ctx context.Context, userID int) (generation int, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `UPDATE users SET token_generation = token_generation + 1
+	WHERE id = $1 RETURNING token_generation`
+	err = s.DB.QueryRowContext(ctx, stmt, userID).Scan(&generation)
+	if err != nil {
+		return 0, err
+	}
+
+	return generation, nil
+}
@@ -0,0 +1,121 @@
+package role
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/postgres"
+)
+
+type store struct {
+	*postgres.Postgres
+}
+
+func New(pg *postgres.Postgres) core.RoleStore {
+	return &store{pg}
+}
+
+func (s *store) GetUserRoles(ctx context.Context, userID int) (roles []string, permissions []string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `SELECT roles.name FROM user_roles
+	JOIN roles ON roles.id = user_roles.role_id
+	WHERE user_roles.user_id = $1 ORDER BY roles.name`
+
+	roles, err = s.names(ctx, stmt, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stmt = `SELECT DISTINCT permissions.name FROM user_roles
+	JOIN role_permissions ON role_permissions.role_id = user_roles.role_id
+	JOIN permissions ON permissions.id = role_permissions.permission_id
+	WHERE user_roles.user_id = $1 ORDER BY permissions.name`
+
+	permissions, err = s.names(ctx, stmt, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return roles, permissions, nil
+}
+
+func (s *store) AddUserRole(ctx context.Context, userID int, role string) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	roleID, err := s.roleID(ctx, role)
+	if err != nil {
+		return err
+	}
+
+	stmt := `INSERT INTO user_roles (user_id, role_id) VALUES ($1, $2)
+	ON CONFLICT DO NOTHING`
+
+	_, err = s.DB.ExecContext(ctx, stmt, userID, roleID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *store) RemoveUserRole(ctx context.Context, userID int, role string) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	roleID, err := s.roleID(ctx, role)
+	if err != nil {
+		return err
+	}
+
+	stmt := `DELETE FROM user_roles WHERE user_id = $1 AND role_id = $2`
+
+	_, err = s.DB.ExecContext(ctx, stmt, userID, roleID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *store) roleID(ctx context.Context, role string) (int, error) {
+	stmt := `SELECT id FROM roles WHERE name = $1`
+
+	var roleID int
+	err := s.DB.QueryRowContext(ctx, stmt, role).Scan(&roleID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, core.ErrRoleNotFound
+		}
+		return 0, err
+	}
+
+	return roleID, nil
+}
+
+func (s *store) names(ctx context.Context, stmt string, args ...any) ([]string, error) {
+	rows, err := s.DB.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+
+		err = rows.Scan(&name)
+		if err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
@@ -0,0 +1,147 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/postgres"
+)
+
+type store struct {
+	*postgres.Postgres
+}
+
+func New(pg *postgres.Postgres) core.SessionStore {
+	return &store{pg}
+}
+
+func (s *store) AddSession(ctx context.Context, session core.Session) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `INSERT INTO sessions (id, user_id, device_name, user_agent, ip)
+	VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := s.DB.ExecContext(ctx, stmt, session.ID, session.UserID, session.DeviceName, session.UserAgent, session.IP)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *store) TouchSession(ctx context.Context, sessionID string, client core.ClientInfo) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `UPDATE sessions SET last_seen_at = NOW(), ip = $2, user_agent = $3
+	WHERE id = $1 AND revoked_at IS NULL`
+
+	_, err := s.DB.ExecContext(ctx, stmt, sessionID, client.IP, client.UserAgent)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *store) GetSessions(ctx context.Context, userID int, activeSince time.Time) ([]core.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `SELECT id, user_id, device_name, user_agent, ip, created_at, last_seen_at
+	FROM sessions WHERE user_id = $1 AND revoked_at IS NULL AND last_seen_at > $2
+	ORDER BY last_seen_at DESC`
+
+	rows, err := s.DB.QueryContext(ctx, stmt, userID, activeSince)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []core.Session
+	for rows.Next() {
+		var session core.Session
+
+		err = rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.DeviceName,
+			&session.UserAgent,
+			&session.IP,
+			&session.CreatedAt,
+			&session.LastSeenAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// RevokeSession revokes session only if it belongs to the user,
+// so users can not revoke sessions of each other
+func (s *store) RevokeSession(ctx context.Context, userID int, sessionID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `UPDATE sessions SET revoked_at = NOW()
+	WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	result, err := s.DB.ExecContext(ctx, stmt, sessionID, userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return core.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (s *store) RevokeUserSessions(ctx context.Context, userID int, exceptSessionID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `UPDATE sessions SET revoked_at = NOW()
+	WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL`
+
+	_, err := s.DB.ExecContext(ctx, stmt, userID, exceptSessionID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// IsSessionRevoked reports false for unknown sessions, tokens issued
+// before sessions were introduced have no session row
+func (s *store) IsSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `SELECT revoked_at IS NOT NULL FROM sessions WHERE id = $1`
+
+	var revoked bool
+	err := s.DB.QueryRowContext(ctx, stmt, sessionID).Scan(&revoked)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return revoked, nil
+}
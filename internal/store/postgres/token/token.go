@@ -0,0 +1,119 @@
+package token
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/postgres"
+)
+
+type store struct {
+	*postgres.Postgres
+}
+
+func New(pg *postgres.Postgres) core.RefreshTokenStore {
+	return &store{pg}
+}
+
+func (s *store) AddRefreshToken(ctx context.Context, token core.RefreshToken) (tokenID int, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `INSERT INTO refresh_tokens (user_id, token_hash, family_id, expires_at)
+	VALUES ($1, $2, $3, $4) RETURNING id`
+
+	err = s.DB.QueryRowContext(ctx, stmt, token.UserID, token.TokenHash, token.FamilyID, token.ExpiresAt).Scan(&tokenID)
+	if err != nil {
+		return 0, err
+	}
+
+	return tokenID, nil
+}
+
+func (s *store) GetRefreshToken(ctx context.Context, tokenHash string) (token *core.RefreshToken, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	token = new(core.RefreshToken)
+
+	stmt := `SELECT id, user_id, token_hash, family_id, expires_at, used_at, revoked_at
+	FROM refresh_tokens WHERE token_hash = $1`
+
+	err = s.DB.QueryRowContext(ctx, stmt, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.FamilyID,
+		&token.ExpiresAt,
+		&token.UsedAt,
+		&token.RevokedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, core.ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// UseRefreshToken marks token as used, the update is conditional
+// so that two concurrent refreshes with the same token can not both succeed
+func (s *store) UseRefreshToken(ctx context.Context, tokenID int) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `UPDATE refresh_tokens SET used_at = NOW()
+	WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL`
+
+	res, err := s.DB.ExecContext(ctx, stmt, tokenID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return core.ErrRefreshTokenReused
+	}
+
+	return nil
+}
+
+func (s *store) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `UPDATE refresh_tokens SET revoked_at = NOW()
+	WHERE family_id = $1 AND revoked_at IS NULL`
+
+	_, err := s.DB.ExecContext(ctx, stmt, familyID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RevokeUserRefreshTokens revokes all tokens of the user, except the family if it is not empty
+func (s *store) RevokeUserRefreshTokens(ctx context.Context, userID int, exceptFamilyID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `UPDATE refresh_tokens SET revoked_at = NOW()
+	WHERE user_id = $1 AND family_id <> $2 AND revoked_at IS NULL`
+
+	_, err := s.DB.ExecContext(ctx, stmt, userID, exceptFamilyID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
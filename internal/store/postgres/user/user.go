@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
@@ -18,14 +19,68 @@ func New(pg *postgres.Postgres) core.UserStore {
 	return &store{pg}
 }
 
-func (s *store) GetUserByUsername(ctx context.Context, username string) (user *core.User, err error) {
+// userColumns are selected by every query that returns users, in order of scanUser
+const userColumns = `id, username, COALESCE(username_canonical, ''), password_hash, pepper_version, COALESCE(email, ''),
+	email_verified_at IS NOT NULL, COALESCE(phone, ''), phone_verified_at IS NOT NULL, disabled_at IS NOT NULL,
+	password_reset_required, created_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row scanner, dest ...any) (*core.User, error) {
+	user := new(core.User)
+
+	dest = append([]any{&user.ID, &user.Username, &user.CanonicalUsername, &user.PasswordHash, &user.PepperVersion,
+		&user.Email, &user.EmailVerified, &user.Phone, &user.PhoneVerified, &user.Disabled, &user.PasswordResetRequired,
+		&user.CreatedAt}, dest...)
+
+	err := row.Scan(dest...)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *store) GetUserByUsername(ctx context.Context, canonicalUsername string) (user *core.User, err error) {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	user = new(core.User)
+	stmt := `SELECT ` + userColumns + ` FROM users WHERE username_canonical = $1`
+	user, err = scanUser(s.DB.QueryRowContext(ctx, stmt, canonicalUsername))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, core.ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *store) GetUserByID(ctx context.Context, userID int) (user *core.User, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
 
-	stmt := `SELECT id, username, password_hash FROM users WHERE username = $1`
-	err = s.DB.QueryRowContext(ctx, stmt, username).Scan(&user.ID, &user.Username, &user.PasswordHash)
+	stmt := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+	user, err = scanUser(s.DB.QueryRowContext(ctx, stmt, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, core.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *store) GetUserByEmail(ctx context.Context, email string) (user *core.User, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `SELECT ` + userColumns + ` FROM users WHERE LOWER(email) = LOWER($1)`
+	user, err = scanUser(s.DB.QueryRowContext(ctx, stmt, email))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, core.ErrInvalidCredentials
@@ -55,20 +110,41 @@ func (s *store) AddUser(ctx context.Context, user core.User) (int, error) {
 	}()
 
 	stmt := `SELECT id FROM users
-	WHERE username = $1`
+	WHERE username_canonical = $1`
 
 	var userID int
-	err = tx.QueryRowContext(ctx, stmt, user.Username).Scan(&userID)
+	err = tx.QueryRowContext(ctx, stmt, user.CanonicalUsername).Scan(&userID)
 	if userID != 0 {
 		return 0, core.ErrUserAlreadyExists
 	} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return 0, err
 	}
 
-	stmt = `INSERT INTO users (username, password_hash)
-	VALUES ($1, $2) RETURNING id`
+	if user.Email != "" {
+		stmt = `SELECT id FROM users
+		WHERE LOWER(email) = LOWER($1)`
+
+		err = tx.QueryRowContext(ctx, stmt, user.Email).Scan(&userID)
+		if userID != 0 {
+			return 0, core.ErrEmailAlreadyExists
+		} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return 0, err
+		}
+	}
+
+	stmt = `INSERT INTO users (username, username_canonical, password_hash, pepper_version, email)
+	VALUES ($1, $2, $3, $4, NULLIF($5, '')) RETURNING id`
+
+	err = tx.QueryRowContext(ctx, stmt, user.Username, user.CanonicalUsername, user.PasswordHash, user.PepperVersion,
+		user.Email).Scan(&userID)
+	if err != nil {
+		return 0, err
+	}
+
+	stmt = `INSERT INTO user_roles (user_id, role_id)
+	SELECT $1, id FROM roles WHERE name = ANY($2)`
 
-	err = tx.QueryRowContext(ctx, stmt, user.Username, user.PasswordHash).Scan(&userID)
+	_, err = tx.ExecContext(ctx, stmt, userID, user.Roles)
 	if err != nil {
 		return 0, err
 	}
@@ -80,11 +156,266 @@ func (s *store) UpdateUser(ctx context.Context, user core.User) (userID int, err
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	stmt := `UPDATE users SET password_hash = $1 WHERE id = $2 RETURNING id`
-	err = s.DB.QueryRowContext(ctx, stmt, user.PasswordHash, user.ID).Scan(&userID)
+	stmt := `UPDATE users SET password_hash = $1, pepper_version = $2, password_reset_required = FALSE
+	WHERE id = $3 RETURNING id`
+	err = s.DB.QueryRowContext(ctx, stmt, user.PasswordHash, user.PepperVersion, user.ID).Scan(&userID)
 	if err != nil {
 		return 0, err
 	}
 
 	return userID, nil
 }
+
+func (s *store) SetEmailVerified(ctx context.Context, userID int, email string) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `UPDATE users SET email_verified_at = NOW()
+	WHERE id = $1 AND LOWER(email) = LOWER($2) AND email_verified_at IS NULL`
+
+	result, err := s.DB.ExecContext(ctx, stmt, userID, email)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return core.ErrInvalidVerificationToken
+	}
+
+	return nil
+}
+
+// SetPhone replaces phone of the user, verification is reset even if the phone is the same
+func (s *store) SetPhone(ctx context.Context, userID int, phone string) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	// starting transaction
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	stmt := `SELECT id FROM users
+	WHERE phone = $1 AND id != $2`
+
+	var otherID int
+	err = tx.QueryRowContext(ctx, stmt, phone, userID).Scan(&otherID)
+	if otherID != 0 {
+		return core.ErrPhoneAlreadyExists
+	} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	stmt = `UPDATE users SET phone = $2, phone_verified_at = NULL
+	WHERE id = $1`
+
+	result, err := tx.ExecContext(ctx, stmt, userID, phone)
+	if err != nil {
+		return err
+	}
+
+	return userAffected(result)
+}
+
+func (s *store) SetPhoneVerified(ctx context.Context, userID int, phone string) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `UPDATE users SET phone_verified_at = NOW()
+	WHERE id = $1 AND phone = $2 AND phone_verified_at IS NULL`
+
+	result, err := s.DB.ExecContext(ctx, stmt, userID, phone)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return core.ErrInvalidOTP
+	}
+
+	return nil
+}
+
+func (s *store) ListUsers(ctx context.Context, query string, role string, afterID int, limit int) ([]core.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `SELECT ` + userColumns + `, COALESCE((SELECT STRING_AGG(roles.name, ',' ORDER BY roles.name)
+		FROM user_roles JOIN roles ON roles.id = user_roles.role_id
+		WHERE user_roles.user_id = users.id), '')
+	FROM users
+	WHERE id > $1
+	AND ($2 = '' OR STRPOS(username_canonical, $2) > 0 OR STRPOS(LOWER(COALESCE(email, '')), $2) > 0)
+	AND ($3 = '' OR EXISTS (SELECT 1 FROM user_roles JOIN roles ON roles.id = user_roles.role_id
+		WHERE user_roles.user_id = users.id AND roles.name = $3))
+	ORDER BY id LIMIT $4`
+
+	rows, err := s.DB.QueryContext(ctx, stmt, afterID, query, role, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []core.User
+	for rows.Next() {
+		var roles string
+
+		user, err := scanUser(rows, &roles)
+		if err != nil {
+			return nil, err
+		}
+
+		if roles != "" {
+			user.Roles = strings.Split(roles, ",")
+		}
+
+		users = append(users, *user)
+	}
+
+	return users, rows.Err()
+}
+
+func (s *store) SetUserDisabled(ctx context.Context, userID int, disabled bool) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	// Time of the first disabling is kept
+	stmt := `UPDATE users SET disabled_at = CASE WHEN $2 THEN COALESCE(disabled_at, NOW()) END
+	WHERE id = $1`
+
+	result, err := s.DB.ExecContext(ctx, stmt, userID, disabled)
+	if err != nil {
+		return err
+	}
+
+	return userAffected(result)
+}
+
+// GetUsersWithoutCanonicalUsername returns users created before canonical usernames, oldest first
+func (s *store) GetUsersWithoutCanonicalUsername(ctx context.Context, limit int) ([]core.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `SELECT ` + userColumns + ` FROM users WHERE username_canonical IS NULL ORDER BY id LIMIT $1`
+
+	rows, err := s.DB.QueryContext(ctx, stmt, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []core.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, *user)
+	}
+
+	return users, rows.Err()
+}
+
+// SetCanonicalUsername sets username and its canonical form of the user that has none,
+// the user is skipped if it already has canonical username
+func (s *store) SetCanonicalUsername(ctx context.Context, user core.User) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	// starting transaction
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	stmt := `SELECT id FROM users
+	WHERE username_canonical = $1 OR (id != $2 AND username = $3)`
+
+	var userID int
+	err = tx.QueryRowContext(ctx, stmt, user.CanonicalUsername, user.ID, user.Username).Scan(&userID)
+	if userID != 0 {
+		return core.ErrUserAlreadyExists
+	} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	stmt = `UPDATE users SET username = $1, username_canonical = $2
+	WHERE id = $3 AND username_canonical IS NULL`
+
+	_, err = tx.ExecContext(ctx, stmt, user.Username, user.CanonicalUsername, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *store) SetPasswordResetRequired(ctx context.Context, userID int) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `UPDATE users SET password_reset_required = TRUE WHERE id = $1`
+
+	result, err := s.DB.ExecContext(ctx, stmt, userID)
+	if err != nil {
+		return err
+	}
+
+	return userAffected(result)
+}
+
+// DeleteUser deletes the user, everything that belongs to the user is deleted by cascade
+func (s *store) DeleteUser(ctx context.Context, userID int) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	stmt := `DELETE FROM users WHERE id = $1`
+
+	result, err := s.DB.ExecContext(ctx, stmt, userID)
+	if err != nil {
+		return err
+	}
+
+	return userAffected(result)
+}
+
+func userAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return core.ErrUserNotFound
+	}
+
+	return nil
+}
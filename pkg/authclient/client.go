@@ -0,0 +1,167 @@
+// Package authclient verifies beatflow-auth access tokens in other beatflow services.
+//
+// Tokens are verified locally with published keys (JWKS) or a shared HS256 secret,
+// when it is not possible the client falls back to Introspect RPC if it is configured.
+// Interceptors put *Principal into context, use FromContext to get it.
+package authclient
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/jwt"
+	authv1 "github.com/MAXXXIMUS-tropical-milkshake/beatflow-protos/gen/go/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+var (
+	ErrUnauthenticated = errors.New("unauthenticated")
+	ErrNoVerifier      = errors.New("neither keys nor introspection are configured")
+	ErrNoValidation    = errors.New("issuer or audience must be configured to verify tokens locally")
+)
+
+type introspection struct {
+	conn         grpc.ClientConnInterface
+	clientID     string
+	clientSecret string
+}
+
+type Client struct {
+	httpClient   *http.Client
+	jwksURL      string
+	jwksConn     grpc.ClientConnInterface
+	jwksRefresh  time.Duration
+	sharedKeyID  string
+	sharedSecret string
+	validation   jwt.ValidationOptions
+
+	introspection *introspection
+	publicMethods map[string]bool
+
+	verifier         *jwt.Verifier
+	introspectClient authv1.AuthClient
+}
+
+func New(opts ...Option) (*Client, error) {
+	c := &Client{
+		httpClient:    http.DefaultClient,
+		jwksRefresh:   _defaultJWKSRefresh,
+		publicMethods: map[string]bool{},
+	}
+
+	// Custom options
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	// Fetcher is built after all options, so it does not depend on their order
+	var fetcher jwksFetcher
+	switch {
+	case c.jwksURL != "":
+		fetcher = fetchJWKSFromURL(c.httpClient, c.jwksURL)
+	case c.jwksConn != nil:
+		fetcher = fetchJWKSFromServer(c.jwksConn)
+	}
+
+	// Other tokens signed with the same keys could be accepted if nothing binds them to the service
+	if (fetcher != nil || c.sharedSecret != "") && c.validation.Issuer == "" && len(c.validation.Audiences) == 0 {
+		return nil, ErrNoValidation
+	}
+
+	switch {
+	case fetcher != nil:
+		c.verifier = jwt.NewVerifier(newJWKSKeySet(fetcher, c.jwksRefresh), c.validation)
+	case c.sharedSecret != "":
+		keyring, err := jwt.NewKeyring(jwt.StaticKey(func() (*jwt.Key, error) {
+			return jwt.NewHMACKey(c.sharedKeyID, c.sharedSecret)
+		}))
+		if err != nil {
+			return nil, err
+		}
+		c.verifier = jwt.NewVerifier(keyring, c.validation)
+	}
+
+	if c.introspection != nil {
+		c.introspectClient = authv1.NewAuthClient(c.introspection.conn)
+	}
+
+	if c.verifier == nil && c.introspectClient == nil {
+		return nil, ErrNoVerifier
+	}
+
+	return c, nil
+}
+
+// Authenticate verifies token and returns its principal
+func (c *Client) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	if c.verifier != nil {
+		claims, err := c.verifier.Verify(token)
+		if err == nil {
+			return principalFromClaims(claims), nil
+		}
+
+		if c.introspectClient == nil || !keysUnavailable(err) {
+			return nil, ErrUnauthenticated
+		}
+	}
+
+	return c.introspect(ctx, token)
+}
+
+func (c *Client) introspect(ctx context.Context, token string) (*Principal, error) {
+	credentials := base64.StdEncoding.EncodeToString([]byte(c.introspection.clientID + ":" + c.introspection.clientSecret))
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Basic "+credentials)
+
+	resp, err := c.introspectClient.Introspect(ctx, &authv1.IntrospectRequest{Token: token})
+	if err != nil {
+		return nil, err
+	}
+
+	if !resp.GetActive() {
+		return nil, ErrUnauthenticated
+	}
+
+	userID, err := strconv.Atoi(resp.GetSub())
+	if err != nil {
+		return nil, ErrUnauthenticated
+	}
+
+	return &Principal{
+		UserID:      userID,
+		SessionID:   resp.GetSid(),
+		TokenID:     resp.GetJti(),
+		Roles:       resp.GetRoles(),
+		Permissions: resp.GetPermissions(),
+		Scopes:      strings.Fields(resp.GetScope()),
+		ExpiresAt:   time.Unix(resp.GetExp(), 0),
+
+		Email:         resp.GetEmail(),
+		EmailVerified: resp.GetEmailVerified(),
+	}, nil
+}
+
+func keysUnavailable(err error) bool {
+	return errors.Is(err, jwt.ErrUnknownKey) || errors.Is(err, errKeysUnavailable)
+}
+
+func principalFromClaims(claims *core.Claims) *Principal {
+	return &Principal{
+		UserID:      claims.UserID,
+		SessionID:   claims.SessionID,
+		TokenID:     claims.ID,
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+		Scopes:      claims.Scopes,
+		ExpiresAt:   claims.ExpiresAt,
+
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+	}
+}
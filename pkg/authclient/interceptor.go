@@ -0,0 +1,89 @@
+package authclient
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor authenticates every call except public methods
+func (c *Client) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		if c.publicMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		ctx, err = c.authenticateContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor authenticates every stream except public methods
+func (c *Client) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if c.publicMethods[info.FullMethod] {
+			return handler(srv, ss)
+		}
+
+		ctx, err := c.authenticateContext(ss.Context())
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func (c *Client) authenticateContext(ctx context.Context) (context.Context, error) {
+	token, ok := TokenFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, ErrUnauthenticated.Error())
+	}
+
+	principal, err := c.Authenticate(ctx, token)
+	if err != nil {
+		if status.Code(err) == codes.Unknown {
+			return nil, status.Error(codes.Unauthenticated, ErrUnauthenticated.Error())
+		}
+		return nil, status.Error(codes.Unavailable, "failed to introspect token")
+	}
+
+	return NewContext(ctx, principal), nil
+}
+
+// TokenFromContext returns bearer token from incoming metadata
+func TokenFromContext(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	authorization := md.Get("authorization")
+	if len(authorization) == 0 {
+		return "", false
+	}
+
+	token := strings.TrimSpace(strings.TrimPrefix(authorization[0], "Bearer"))
+	if token == "" {
+		return "", false
+	}
+
+	return token, true
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
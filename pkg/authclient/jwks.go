@@ -0,0 +1,158 @@
+package authclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/core"
+	"github.com/MAXXXIMUS-tropical-milkshake/beatflow-auth/internal/lib/jwt"
+	authv1 "github.com/MAXXXIMUS-tropical-milkshake/beatflow-protos/gen/go/auth"
+	"google.golang.org/grpc"
+)
+
+var errKeysUnavailable = errors.New("verification keys are unavailable")
+
+const fetchTimeout = 5 * time.Second
+
+type jwksFetcher func(ctx context.Context) ([]core.JWK, error)
+
+// jwksKeySet caches published keys, unknown kid triggers refetch
+// but not more often than minRefresh, so forged kids can not flood the server
+type jwksKeySet struct {
+	fetch      jwksFetcher
+	refresh    time.Duration
+	minRefresh time.Duration
+
+	mu        sync.RWMutex
+	keys      map[string]*jwt.Key
+	fetchedAt time.Time
+}
+
+func newJWKSKeySet(fetch jwksFetcher, refresh time.Duration) *jwksKeySet {
+	return &jwksKeySet{
+		fetch:      fetch,
+		refresh:    refresh,
+		minRefresh: refresh / 10,
+	}
+}
+
+func (s *jwksKeySet) VerificationKey(kid string) (*jwt.Key, error) {
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	fresh := time.Since(s.fetchedAt) < s.refresh
+	s.mu.RUnlock()
+
+	if ok && fresh {
+		return key, nil
+	}
+
+	if err := s.update(); err != nil {
+		// Stale key is better than no key while server is unavailable
+		if ok {
+			return key, nil
+		}
+		return nil, fmt.Errorf("%w: %w", errKeysUnavailable, err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok = s.keys[kid]
+	if !ok {
+		return nil, jwt.ErrUnknownKey
+	}
+
+	return key, nil
+}
+
+func (s *jwksKeySet) update() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.fetchedAt) < s.minRefresh {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+
+	jwks, err := s.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*jwt.Key, len(jwks))
+	for _, jwk := range jwks {
+		key, err := jwt.KeyFromJWK(jwk)
+		if err != nil {
+			continue
+		}
+		keys[key.ID] = key
+	}
+
+	s.keys = keys
+	s.fetchedAt = time.Now()
+
+	return nil
+}
+
+func fetchJWKSFromURL(httpClient *http.Client, url string) jwksFetcher {
+	return func(ctx context.Context) ([]core.JWK, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected jwks status: %s", resp.Status)
+		}
+
+		var body struct {
+			Keys []core.JWK `json:"keys"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return nil, err
+		}
+
+		return body.Keys, nil
+	}
+}
+
+func fetchJWKSFromServer(conn grpc.ClientConnInterface) jwksFetcher {
+	client := authv1.NewAuthClient(conn)
+
+	return func(ctx context.Context) ([]core.JWK, error) {
+		resp, err := client.GetJWKS(ctx, &authv1.GetJWKSRequest{})
+		if err != nil {
+			return nil, err
+		}
+
+		keys := make([]core.JWK, 0, len(resp.GetKeys()))
+		for _, key := range resp.GetKeys() {
+			keys = append(keys, core.JWK{
+				Kty: key.GetKty(),
+				Kid: key.GetKid(),
+				Use: key.GetUse(),
+				Alg: key.GetAlg(),
+				N:   key.GetN(),
+				E:   key.GetE(),
+				Crv: key.GetCrv(),
+				X:   key.GetX(),
+				Y:   key.GetY(),
+			})
+		}
+
+		return keys, nil
+	}
+}
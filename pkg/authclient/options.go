@@ -0,0 +1,87 @@
+package authclient
+
+import (
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+const _defaultJWKSRefresh = 10 * time.Minute
+
+// Option -.
+type Option func(*Client)
+
+// WithJWKSURL verifies tokens locally with keys from /.well-known/jwks.json,
+// they are fetched with the client of WithHTTPClient regardless of option order
+func WithJWKSURL(url string) Option {
+	return func(c *Client) {
+		c.jwksURL, c.jwksConn = url, nil
+	}
+}
+
+// WithJWKSFromServer verifies tokens locally with keys from GetJWKS RPC
+func WithJWKSFromServer(conn grpc.ClientConnInterface) Option {
+	return func(c *Client) {
+		c.jwksURL, c.jwksConn = "", conn
+	}
+}
+
+// WithJWKSRefresh sets how often published keys are fetched again
+func WithJWKSRefresh(refresh time.Duration) Option {
+	return func(c *Client) {
+		c.jwksRefresh = refresh
+	}
+}
+
+// WithHTTPClient sets client used for fetching JWKS
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithSharedSecret verifies HS256 tokens locally, keyID is the kid of the tokens
+func WithSharedSecret(keyID string, secret string) Option {
+	return func(c *Client) {
+		c.sharedKeyID, c.sharedSecret = keyID, secret
+	}
+}
+
+// WithIntrospection falls back to Introspect RPC when token can not be verified locally,
+// without local keys every token is introspected
+func WithIntrospection(conn grpc.ClientConnInterface, clientID string, clientSecret string) Option {
+	return func(c *Client) {
+		c.introspection = &introspection{conn: conn, clientID: clientID, clientSecret: clientSecret}
+	}
+}
+
+// WithIssuer sets issuer of accepted tokens, issuer or audience is required with local keys
+func WithIssuer(issuer string) Option {
+	return func(c *Client) {
+		c.validation.Issuer = issuer
+	}
+}
+
+// WithAudience sets audiences accepted by the service, issuer or audience is required with local keys
+func WithAudience(audience ...string) Option {
+	return func(c *Client) {
+		c.validation.Audiences = audience
+	}
+}
+
+// WithLeeway sets allowed clock skew
+func WithLeeway(leeway time.Duration) Option {
+	return func(c *Client) {
+		c.validation.Leeway = leeway
+	}
+}
+
+// WithPublicMethods sets full method names that do not require token
+func WithPublicMethods(methods ...string) Option {
+	return func(c *Client) {
+		for _, method := range methods {
+			c.publicMethods[method] = true
+		}
+	}
+}
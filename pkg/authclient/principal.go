@@ -0,0 +1,46 @@
+package authclient
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+type contextKey struct{}
+
+// Principal is the authenticated user of the call
+type Principal struct {
+	UserID      int
+	SessionID   string
+	TokenID     string
+	Roles       []string
+	Permissions []string
+	Scopes      []string
+	ExpiresAt   time.Time
+	// Email is empty if the user has none
+	Email         string
+	EmailVerified bool
+}
+
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+func (p *Principal) HasPermission(permission string) bool {
+	return slices.Contains(p.Permissions, permission)
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// NewContext returns context that carries principal
+func NewContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+// FromContext returns principal put into context by interceptors
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(contextKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
@@ -0,0 +1,3 @@
+module github.com/MAXXXIMUS-tropical-milkshake/beatflow-protos
+
+go 1.23.1